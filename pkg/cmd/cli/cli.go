@@ -66,6 +66,41 @@ func NewCommandCLI(name, fullName string, in io.Reader, out, errout io.Writer) *
 				loginCmd,
 			},
 		},
+		{
+			Message: "Cluster Management Commands:",
+			Commands: []*cobra.Command{
+				oshinkocmd.NewCmdExport(fullName, f, out),
+				oshinkocmd.NewCmdClone(fullName, f, out),
+				oshinkocmd.NewCmdUpgrade(fullName, f, out),
+				oshinkocmd.NewCmdRollback(fullName, f, out),
+				oshinkocmd.NewCmdHistory(fullName, f, out),
+				oshinkocmd.NewCmdIdle(fullName, f, out),
+				oshinkocmd.NewCmdUnidle(fullName, f, out),
+				oshinkocmd.NewCmdTTL(fullName, f, out),
+				oshinkocmd.NewCmdGc(fullName, f, out),
+				oshinkocmd.NewCmdDoctor(fullName, f, out),
+				oshinkocmd.NewCmdAdopt(fullName, f, out),
+				oshinkocmd.NewCmdCheckCapacity(fullName, f, out),
+				oshinkocmd.NewCmdResources(fullName, f, out),
+				oshinkocmd.NewCmdPlacement(fullName, f, out),
+				oshinkocmd.NewCmdStorage(fullName, f, out),
+				oshinkocmd.NewCmdHistoryServer(fullName, f, out),
+				oshinkocmd.NewCmdMetrics(fullName, f, out),
+				oshinkocmd.NewCmdTop(fullName, f, out),
+				oshinkocmd.NewCmdDashboard(fullName, f, out),
+				oshinkocmd.NewCmdCredentials(fullName, f, out),
+				oshinkocmd.NewCmdSecure(fullName, f, out),
+				oshinkocmd.NewCmdExpose(fullName, f, out),
+				oshinkocmd.NewCmdIsolate(fullName, f, out),
+				oshinkocmd.NewCmdLink(fullName, f, out),
+				oshinkocmd.NewCmdUnlink(fullName, f, out),
+				oshinkocmd.NewCmdNewApp(fullName, f, out),
+				oshinkocmd.NewCmdSchedule(fullName, f, out),
+				oshinkocmd.NewCmdCp(fullName, f, out),
+				oshinkocmd.NewCmdPythonDeps(fullName, f, out),
+				oshinkocmd.NewCmdDescribe(fullName, f, out),
+			},
+		},
 	}
 	groups.Add(cmds)
 
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/labels"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	adoptLong = `
+Bring an existing spark standalone deployment under oshinko management.
+
+The master and worker deployment configs are checked to look like a spark
+standalone cluster: the master must expose the spark master port and the
+workers must refer to a spark:// master url. The oshinko-type and
+oshinko-cluster labels are then added to both deployment configs, their pod
+templates and the services in front of the master. Services providing the
+spark-master and spark-webui ports are created when missing, so the cluster
+shows up in the cluster listing. Nothing is changed when another service
+already uses the name of a service to create.`
+
+	adoptExample = `  # Adopt the deployments spark-master and spark-worker as the cluster 'legacy'
+  %[1]s adopt legacy --master-dc spark-master --worker-dc spark-worker`
+)
+
+type AdoptOptions struct {
+	ClusterCmdOptions
+
+	MasterDC string
+	WorkerDC string
+}
+
+// NewCmdAdopt implements the oshinko adopt command
+func NewCmdAdopt(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &AdoptOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "adopt <NAME> --master-dc <DC> --worker-dc <DC>",
+		Short:   "Manage an existing spark deployment as a cluster",
+		Long:    adoptLong,
+		Example: fmt.Sprintf(adoptExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunAdopt(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.MasterDC, "master-dc", "", "The deployment config running the spark master")
+	cmd.Flags().StringVar(&options.WorkerDC, "worker-dc", "", "The deployment config running the spark workers")
+	return cmd
+}
+
+func (o *AdoptOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if err := o.ClusterCmdOptions.Complete(f, args, out); err != nil {
+		return err
+	}
+	if err := checkNewClusterName(o.Name); err != nil {
+		return err
+	}
+	if o.MasterDC == "" || o.WorkerDC == "" {
+		return fmt.Errorf("--master-dc and --worker-dc must be specified")
+	}
+	return nil
+}
+
+// hasContainerPort reports whether a container declares a port
+func hasContainerPort(c *kapi.Container, port int) bool {
+	for _, p := range c.Ports {
+		if p.ContainerPort == port {
+			return true
+		}
+	}
+	return false
+}
+
+// refersToSparkMaster reports whether a container is configured with a spark:// master url
+func refersToSparkMaster(c *kapi.Container) bool {
+	values := append([]string{}, c.Command...)
+	values = append(values, c.Args...)
+	for _, e := range c.Env {
+		values = append(values, e.Value)
+	}
+	for _, v := range values {
+		if strings.Contains(v, "spark://") {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSparkDeployments checks that a pair of deployment configs look like
+// the master and the workers of a spark standalone cluster
+func validateSparkDeployments(master, worker *deployapi.DeploymentConfig) error {
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if sparkContainer(dc.Spec.Template) == nil {
+			return fmt.Errorf("deployment config %s has no containers", dc.Name)
+		}
+		if name, ok := dc.Labels[clusterLabel]; ok {
+			return fmt.Errorf("deployment config %s already belongs to cluster %q", dc.Name, name)
+		}
+	}
+	if !hasContainerPort(sparkContainer(master.Spec.Template), masterPort) {
+		return fmt.Errorf("deployment config %s does not expose the spark master port %d", master.Name, masterPort)
+	}
+	if !refersToSparkMaster(sparkContainer(worker.Spec.Template)) {
+		return fmt.Errorf("deployment config %s does not refer to a spark:// master url", worker.Name)
+	}
+	return nil
+}
+
+// addClusterLabels labels a deployment config and its pod template
+func addClusterLabels(dc *deployapi.DeploymentConfig, otype, clustername string) {
+	for k, v := range clusterLabels(otype, clustername) {
+		if dc.Labels == nil {
+			dc.Labels = make(map[string]string)
+		}
+		dc.Labels[k] = v
+		if dc.Spec.Template.Labels == nil {
+			dc.Spec.Template.Labels = make(map[string]string)
+		}
+		dc.Spec.Template.Labels[k] = v
+	}
+}
+
+// servicePort returns the port of a service matching a port number or name
+func servicePort(srv *kapi.Service, port int, name string) *kapi.ServicePort {
+	for i := range srv.Spec.Ports {
+		if srv.Spec.Ports[i].Port == port || srv.Spec.Ports[i].Name == name {
+			return &srv.Spec.Ports[i]
+		}
+	}
+	return nil
+}
+
+func (o *AdoptOptions) RunAdopt() error {
+	if _, _, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name); err == nil {
+		return fmt.Errorf("cluster %q already exists", o.Name)
+	}
+	master, err := o.Client.DeploymentConfigs(o.Project).Get(o.MasterDC)
+	if err != nil {
+		return err
+	}
+	worker, err := o.Client.DeploymentConfigs(o.Project).Get(o.WorkerDC)
+	if err != nil {
+		return err
+	}
+	if err := validateSparkDeployments(master, worker); err != nil {
+		return err
+	}
+
+	// find the services in front of the master before its labels change
+	srvs, err := o.KClient.Services(o.Project).List(kapi.ListOptions{})
+	if err != nil {
+		return err
+	}
+	masterPodLabels := labels.Set(master.Spec.Template.Labels)
+	var masterSrv, webSrv *kapi.Service
+	for i := range srvs.Items {
+		srv := &srvs.Items[i]
+		if len(srv.Spec.Selector) == 0 || !labels.SelectorFromSet(srv.Spec.Selector).Matches(masterPodLabels) {
+			continue
+		}
+		switch {
+		case masterSrv == nil && servicePort(srv, masterPort, masterPortName) != nil:
+			masterSrv = srv
+		case webSrv == nil && servicePort(srv, webPort, webPortName) != nil:
+			webSrv = srv
+		}
+	}
+
+	services := []struct {
+		srv      *kapi.Service
+		name     string
+		otype    string
+		portname string
+		port     int
+	}{
+		{masterSrv, masterServiceName(o.Name), masterType, masterPortName, masterPort},
+		{webSrv, webuiServiceName(o.Name), webuiType, webPortName, webPort},
+	}
+
+	// the services to create must not clash with existing ones, check before
+	// anything is changed so a failure leaves the deployments alone
+	for _, s := range services {
+		if s.srv != nil {
+			continue
+		}
+		for i := range srvs.Items {
+			if srvs.Items[i].Name == s.name {
+				return fmt.Errorf("service %s already exists but does not expose the %s port of %s, rename or delete it first", s.name, s.portname, master.Name)
+			}
+		}
+	}
+
+	addClusterLabels(master, masterType, o.Name)
+	if _, err := updateAndDeploy(o.Client, o.Project, master); err != nil {
+		return err
+	}
+	addClusterLabels(worker, workerType, o.Name)
+	if _, err := updateAndDeploy(o.Client, o.Project, worker); err != nil {
+		return err
+	}
+
+	for _, s := range services {
+		if s.srv == nil {
+			srv := newClusterService(s.name, o.Name, s.otype, s.portname, s.port)
+			if _, err := o.KClient.Services(o.Project).Create(srv); err != nil {
+				return err
+			}
+			fmt.Fprintf(o.Out, "created service %s for port %s\n", srv.Name, s.portname)
+			continue
+		}
+		for k, v := range clusterLabels(s.otype, o.Name) {
+			if s.srv.Labels == nil {
+				s.srv.Labels = make(map[string]string)
+			}
+			s.srv.Labels[k] = v
+		}
+		if p := servicePort(s.srv, s.port, s.portname); p.Name == "" {
+			p.Name = s.portname
+		}
+		if _, err := o.KClient.Services(o.Project).Update(s.srv); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(o.Out, "cluster %q adopted from %s and %s\n", o.Name, master.Name, worker.Name)
+	return nil
+}
@@ -35,8 +35,12 @@ func (o *AuthOptions) RunClusters(currentProject string) error {
 	if err != nil {
 		return err
 	}
+	oclient, err := client.New(o.Config)
+	if err != nil {
+		return err
+	}
 	var msg string
-	clusters, err := getClusters(kubeclient, currentProject)
+	clusters, err := getClusters(oclient, kubeclient, currentProject)
 	if err == nil {
 		clusterCount := len(clusters)
 		if clusterCount <= 0 {
@@ -50,10 +54,11 @@ func (o *AuthOptions) RunClusters(currentProject string) error {
 				count = count + 1
 				displayName := *(cluster.Name)
 				workCount := *(cluster.WorkerCount)
+				status := *(cluster.Status)
 				//fmt.Println(displayName)
 				linebreak := "\n"
 
-				msg += fmt.Sprintf(linebreak+asterisk+"%s \t  %d", displayName, workCount)
+				msg += fmt.Sprintf(linebreak+asterisk+"%s \t  %d \t  %s", displayName, workCount, status)
 			}
 		}
 
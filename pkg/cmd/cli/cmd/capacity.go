@@ -0,0 +1,380 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/resource"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	capacityLong = `
+Check whether a cluster can grow to a given number of workers.
+
+The cost in cpu, memory and pods of the additional pods is computed from the
+pod templates of the cluster, using the defaults of the project's limit
+ranges for containers which do not set requests or limits. The cost is
+compared with what is left of every resource quota in the project and a
+breakdown is printed. Quotas with scopes, such as Terminating or BestEffort,
+only count the pods they select. The same check runs before any command which
+grows a cluster or the resources of its pods.
+
+The number of workers defaults to the current one. For an idled cluster it
+defaults to the number of workers the cluster had when it was idled.`
+
+	capacityExample = `  # Check whether the cluster 'mycluster' can grow to 10 workers
+  %[1]s check-capacity mycluster --workers 10`
+)
+
+// podShape is a number of additional pods created from one template. A
+// negative count stands for pods going away, such as the pods replaced when a
+// template changes.
+type podShape struct {
+	Template *kapi.PodTemplateSpec
+	Count    int
+}
+
+// resourceCost is the cost of pods in the units quotas are expressed in:
+// millicores for cpu and bytes for memory
+type resourceCost struct {
+	Pods           int64
+	RequestsCPU    int64
+	LimitsCPU      int64
+	RequestsMemory int64
+	LimitsMemory   int64
+}
+
+func (c *resourceCost) add(o resourceCost, times int64) {
+	c.Pods += o.Pods * times
+	c.RequestsCPU += o.RequestsCPU * times
+	c.LimitsCPU += o.LimitsCPU * times
+	c.RequestsMemory += o.RequestsMemory * times
+	c.LimitsMemory += o.LimitsMemory * times
+}
+
+// amount returns the part of the cost counted against a quota resource
+func (c resourceCost) amount(name kapi.ResourceName) (int64, bool) {
+	switch name {
+	case kapi.ResourcePods:
+		return c.Pods, true
+	case kapi.ResourceCPU, kapi.ResourceRequestsCPU:
+		return c.RequestsCPU, true
+	case kapi.ResourceMemory, kapi.ResourceRequestsMemory:
+		return c.RequestsMemory, true
+	case kapi.ResourceLimitsCPU:
+		return c.LimitsCPU, true
+	case kapi.ResourceLimitsMemory:
+		return c.LimitsMemory, true
+	}
+	return 0, false
+}
+
+// quantityAmount converts a quantity to the units of resourceCost
+func quantityAmount(name kapi.ResourceName, q resource.Quantity) int64 {
+	switch name {
+	case kapi.ResourceCPU, kapi.ResourceRequestsCPU, kapi.ResourceLimitsCPU:
+		return q.MilliValue()
+	}
+	return q.Value()
+}
+
+// formatAmount prints an amount in the units of resourceCost
+func formatAmount(name kapi.ResourceName, v int64) string {
+	switch name {
+	case kapi.ResourceCPU, kapi.ResourceRequestsCPU, kapi.ResourceLimitsCPU:
+		return resource.NewMilliQuantity(v, resource.DecimalSI).String()
+	case kapi.ResourceMemory, kapi.ResourceRequestsMemory, kapi.ResourceLimitsMemory:
+		return resource.NewQuantity(v, resource.BinarySI).String()
+	}
+	return fmt.Sprintf("%d", v)
+}
+
+// containerResource returns the request or limit of a container for a
+// resource, falling back to the defaults of the limit ranges
+func containerResource(c *kapi.Container, limitRanges []kapi.LimitRange, name kapi.ResourceName, limit bool) (resource.Quantity, bool) {
+	own := c.Resources.Requests
+	if limit {
+		own = c.Resources.Limits
+	}
+	if q, ok := own[name]; ok {
+		return q, true
+	}
+	for _, lr := range limitRanges {
+		for _, item := range lr.Spec.Limits {
+			if item.Type != kapi.LimitTypeContainer {
+				continue
+			}
+			defaults := item.DefaultRequest
+			if limit {
+				defaults = item.Default
+			}
+			if q, ok := defaults[name]; ok {
+				return q, true
+			}
+		}
+	}
+	// a container with only a limit requests its limit
+	if !limit {
+		return containerResource(c, limitRanges, name, true)
+	}
+	return resource.Quantity{}, false
+}
+
+// templateCost computes the cost of a single pod created from a template. It
+// is an error for a container to exceed the maximum of a limit range.
+func templateCost(template *kapi.PodTemplateSpec, limitRanges []kapi.LimitRange) (resourceCost, error) {
+	cost := resourceCost{Pods: 1}
+	for i := range template.Spec.Containers {
+		c := &template.Spec.Containers[i]
+		for _, name := range []kapi.ResourceName{kapi.ResourceCPU, kapi.ResourceMemory} {
+			req, hasReq := containerResource(c, limitRanges, name, false)
+			lim, hasLim := containerResource(c, limitRanges, name, true)
+			for _, lr := range limitRanges {
+				for _, item := range lr.Spec.Limits {
+					max, ok := item.Max[name]
+					if !ok || item.Type != kapi.LimitTypeContainer {
+						continue
+					}
+					if (hasLim && lim.Cmp(max) > 0) || (hasReq && req.Cmp(max) > 0) {
+						return cost, fmt.Errorf("container %s exceeds the %s maximum of %s set by limit range %s", c.Name, name, max.String(), lr.Name)
+					}
+				}
+			}
+			if name == kapi.ResourceCPU {
+				cost.RequestsCPU += req.MilliValue()
+				cost.LimitsCPU += lim.MilliValue()
+			} else {
+				cost.RequestsMemory += req.Value()
+				cost.LimitsMemory += lim.Value()
+			}
+		}
+	}
+	return cost, nil
+}
+
+// clusterGrowth returns the pods added when a cluster goes from its current
+// replica counts to the given ones
+func clusterGrowth(master, worker *deployapi.DeploymentConfig, masterReplicas, workerReplicas int) []podShape {
+	shapes := []podShape{}
+	if master != nil && masterReplicas > master.Spec.Replicas {
+		shapes = append(shapes, podShape{master.Spec.Template, masterReplicas - master.Spec.Replicas})
+	}
+	if worker != nil && workerReplicas > worker.Spec.Replicas {
+		shapes = append(shapes, podShape{worker.Spec.Template, workerReplicas - worker.Spec.Replicas})
+	}
+	return shapes
+}
+
+// quotaCovers reports whether the scopes of a quota select the pods created
+// from a template. Pods without any request or limit are best effort.
+func quotaCovers(q *kapi.ResourceQuota, template *kapi.PodTemplateSpec, cost resourceCost) bool {
+	terminating := template.Spec.ActiveDeadlineSeconds != nil
+	bestEffort := cost.RequestsCPU == 0 && cost.LimitsCPU == 0 && cost.RequestsMemory == 0 && cost.LimitsMemory == 0
+	for _, scope := range q.Spec.Scopes {
+		switch scope {
+		case kapi.ResourceQuotaScopeTerminating:
+			if !terminating {
+				return false
+			}
+		case kapi.ResourceQuotaScopeNotTerminating:
+			if terminating {
+				return false
+			}
+		case kapi.ResourceQuotaScopeBestEffort:
+			if !bestEffort {
+				return false
+			}
+		case kapi.ResourceQuotaScopeNotBestEffort:
+			if bestEffort {
+				return false
+			}
+		default:
+			// a scope unknown to oshinko cannot be matched
+			return false
+		}
+	}
+	return true
+}
+
+// capacityLine compares the cost of new pods with one resource of one quota
+type capacityLine struct {
+	Quota     string
+	Resource  kapi.ResourceName
+	Hard      int64
+	Used      int64
+	Requested int64
+}
+
+func (l capacityLine) fits() bool {
+	return l.Used+l.Requested <= l.Hard
+}
+
+type byQuotaResource []capacityLine
+
+func (l byQuotaResource) Len() int      { return len(l) }
+func (l byQuotaResource) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l byQuotaResource) Less(i, j int) bool {
+	if l[i].Quota == l[j].Quota {
+		return l[i].Resource < l[j].Resource
+	}
+	return l[i].Quota < l[j].Quota
+}
+
+// capacityReport computes the cost of the new pods and compares it with every
+// resource quota of the namespace
+func capacityReport(kClient *kclient.Client, namespace string, shapes []podShape) ([]capacityLine, error) {
+	lrs, err := kClient.LimitRanges(namespace).List(kapi.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	costs := make([]resourceCost, len(shapes))
+	for i, s := range shapes {
+		if s.Template == nil {
+			continue
+		}
+		if costs[i], err = templateCost(s.Template, lrs.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	quotas, err := kClient.ResourceQuotas(namespace).List(kapi.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	lines := []capacityLine{}
+	for i := range quotas.Items {
+		q := &quotas.Items[i]
+		total := resourceCost{}
+		for j, s := range shapes {
+			if s.Template != nil && quotaCovers(q, s.Template, costs[j]) {
+				total.add(costs[j], int64(s.Count))
+			}
+		}
+		for name, hard := range q.Status.Hard {
+			requested, ok := total.amount(name)
+			if !ok {
+				continue
+			}
+			used := q.Status.Used[name]
+			lines = append(lines, capacityLine{
+				Quota:     q.Name,
+				Resource:  name,
+				Hard:      quantityAmount(name, hard),
+				Used:      quantityAmount(name, used),
+				Requested: requested,
+			})
+		}
+	}
+	sort.Sort(byQuotaResource(lines))
+	return lines, nil
+}
+
+func printCapacityReport(out io.Writer, lines []capacityLine) {
+	w := kubectl.GetNewTabWriter(out)
+	defer w.Flush()
+	fmt.Fprintln(w, "QUOTA\tRESOURCE\tHARD\tUSED\tREQUESTED\tFITS")
+	for _, l := range lines {
+		fits := "yes"
+		if !l.fits() {
+			fits = "no"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", l.Quota, l.Resource, formatAmount(l.Resource, l.Hard), formatAmount(l.Resource, l.Used), formatAmount(l.Resource, l.Requested), fits)
+	}
+}
+
+// checkCapacity refuses with a breakdown when new pods would not fit in the
+// resource quotas of a namespace
+func checkCapacity(kClient *kclient.Client, namespace string, shapes []podShape) error {
+	if len(shapes) == 0 {
+		return nil
+	}
+	lines, err := capacityReport(kClient, namespace, shapes)
+	if err != nil {
+		return err
+	}
+	for _, l := range lines {
+		if !l.fits() {
+			buf := &bytes.Buffer{}
+			printCapacityReport(buf, lines)
+			return fmt.Errorf("the requested pods do not fit in the quota of project %q:\n%s", namespace, buf.String())
+		}
+	}
+	return nil
+}
+
+type CapacityOptions struct {
+	ClusterCmdOptions
+
+	Workers int
+}
+
+// NewCmdCheckCapacity implements the oshinko check-capacity command
+func NewCmdCheckCapacity(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &CapacityOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "check-capacity <NAME> --workers <N>",
+		Short:   "Check whether a cluster fits the project quota at a given size",
+		Long:    capacityLong,
+		Example: fmt.Sprintf(capacityExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunCheckCapacity(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&options.Workers, "workers", -1, "The number of workers to check for, defaults to the current worker count or the count before idling")
+	return cmd
+}
+
+func (o *CapacityOptions) RunCheckCapacity() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	workers := o.Workers
+	if workers < 0 && worker != nil {
+		// an idled cluster comes back at the size it was idled at
+		if workers, err = activeReplicas(worker); err != nil {
+			return err
+		}
+	}
+	// an idled master has to come back too
+	shapes := clusterGrowth(master, worker, 1, workers)
+	if len(shapes) == 0 {
+		fmt.Fprintf(o.Out, "cluster %q does not grow at %d workers\n", o.Name, workers)
+		return nil
+	}
+
+	lines, err := capacityReport(o.KClient, o.Project, shapes)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		fmt.Fprintf(o.Out, "project %q has no resource quota, cluster %q fits at %d workers\n", o.Project, o.Name, workers)
+		return nil
+	}
+	printCapacityReport(o.Out, lines)
+	for _, l := range lines {
+		if !l.fits() {
+			return fmt.Errorf("cluster %q does not fit at %d workers", o.Name, workers)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kapierrors "k8s.io/kubernetes/pkg/api/errors"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/util/sets"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deployreaper "github.com/openshift/origin/pkg/deploy/reaper"
+	routeapi "github.com/openshift/origin/pkg/route/api"
+)
+
+const (
+	cloneLong = `
+Create a new spark cluster as a copy of an existing one.
+
+The deployment configs, services and routes of the source cluster are copied
+along with the config maps and secrets they reference. Labels, service names
+and master urls are rewritten for the new cluster name. When cloning into
+another project, referenced config maps and secrets which already exist there
+are left untouched. Config maps and secrets without the cluster label are
+shared, they are never renamed and the clone refers to them by their name.
+
+If an object cannot be created, the objects already created for the clone are
+deleted again.`
+
+	cloneExample = `  # Create the cluster 'mycopy' with the same shape as 'prod-etl'
+  %[1]s clone prod-etl mycopy
+
+  # Create a copy of 'prod-etl' with 2 workers in the project 'sandbox'
+  %[1]s clone prod-etl mycopy --to-namespace sandbox --workers 2`
+)
+
+type CloneOptions struct {
+	ClusterCmdOptions
+
+	Destination string
+	ToNamespace string
+	Workers     int
+}
+
+// NewCmdClone implements the oshinko clone command
+func NewCmdClone(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &CloneOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "clone <SOURCE> <DESTINATION>",
+		Short:   "Copy a cluster to a new name or project",
+		Long:    cloneLong,
+		Example: fmt.Sprintf(cloneExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunClone(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.ToNamespace, "to-namespace", "", "The project to create the new cluster in, defaults to the current project")
+	cmd.Flags().IntVar(&options.Workers, "workers", -1, "The number of workers in the new cluster, defaults to the source worker count")
+	return cmd
+}
+
+func (o *CloneOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("a source and a destination cluster name must be specified")
+	}
+	if err := o.ClusterCmdOptions.Complete(f, args[:1], out); err != nil {
+		return err
+	}
+	o.Destination = args[1]
+	if err := checkNewClusterName(o.Destination); err != nil {
+		return err
+	}
+	if o.ToNamespace == "" {
+		o.ToNamespace = o.Project
+	}
+	if o.Destination == o.Name && o.ToNamespace == o.Project {
+		return fmt.Errorf("the destination must differ from the source cluster")
+	}
+	return nil
+}
+
+// createClusterObject creates a single exported cluster object in a namespace
+func createClusterObject(oClient *client.Client, kClient *kclient.Client, namespace string, obj runtime.Object) error {
+	var err error
+	switch t := obj.(type) {
+	case *deployapi.DeploymentConfig:
+		_, err = oClient.DeploymentConfigs(namespace).Create(t)
+	case *kapi.Service:
+		_, err = kClient.Services(namespace).Create(t)
+	case *routeapi.Route:
+		_, err = oClient.Routes(namespace).Create(t)
+	case *kapi.ConfigMap:
+		_, err = kClient.ConfigMaps(namespace).Create(t)
+	case *kapi.Secret:
+		_, err = kClient.Secrets(namespace).Create(t)
+	default:
+		err = fmt.Errorf("unable to create object of type %T", obj)
+	}
+	return err
+}
+
+// deleteClusterObject deletes an object created by createClusterObject
+func deleteClusterObject(oClient *client.Client, kClient *kclient.Client, namespace string, obj runtime.Object) error {
+	meta, err := kapi.ObjectMetaFor(obj)
+	if err != nil {
+		return err
+	}
+	switch obj.(type) {
+	case *deployapi.DeploymentConfig:
+		// the deployment config may have started a deployment already
+		reaper := deployreaper.NewDeploymentConfigReaper(oClient, kClient)
+		return reaper.Stop(namespace, meta.Name, reapTimeout, nil)
+	case *kapi.Service:
+		return kClient.Services(namespace).Delete(meta.Name)
+	case *routeapi.Route:
+		return oClient.Routes(namespace).Delete(meta.Name)
+	case *kapi.ConfigMap:
+		return kClient.ConfigMaps(namespace).Delete(meta.Name)
+	case *kapi.Secret:
+		return kClient.Secrets(namespace).Delete(meta.Name)
+	}
+	return fmt.Errorf("unable to delete object of type %T", obj)
+}
+
+// rollbackClone deletes the objects created for a clone which failed
+func (o *CloneOptions) rollbackClone(cause error, created []runtime.Object) error {
+	for i := len(created) - 1; i >= 0; i-- {
+		if err := deleteClusterObject(o.Client, o.KClient, o.ToNamespace, created[i]); err != nil && !kapierrors.IsNotFound(err) {
+			return fmt.Errorf("%v, and removing the partial clone failed: %v", cause, err)
+		}
+	}
+	return cause
+}
+
+func (o *CloneOptions) RunClone() error {
+	if _, _, err := clusterDeploymentConfigs(o.Client, o.ToNamespace, o.Destination); err == nil {
+		return fmt.Errorf("cluster %q already exists in project %q", o.Destination, o.ToNamespace)
+	}
+
+	objects, err := exportClusterObjects(o.Client, o.KClient, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+
+	// every pod of the clone is new in the target project
+	shapes := []podShape{}
+	for _, obj := range objects {
+		if dc, ok := obj.(*deployapi.DeploymentConfig); ok {
+			replicas := dc.Spec.Replicas
+			if dc.Labels[typeLabel] == workerType && o.Workers >= 0 {
+				replicas = o.Workers
+			}
+			shapes = append(shapes, podShape{dc.Spec.Template, replicas})
+		}
+	}
+	if err := checkCapacity(o.KClient, o.ToNamespace, shapes); err != nil {
+		return err
+	}
+
+	// config maps and secrets shared with other clusters only need to be
+	// present in the target project, they are never renamed
+	owned := func(obj runtime.Object) bool {
+		meta, err := kapi.ObjectMetaFor(obj)
+		return err != nil || meta.Labels[clusterLabel] == o.Name
+	}
+	renamed := sets.NewString()
+	for _, obj := range objects {
+		if meta, err := kapi.ObjectMetaFor(obj); err == nil && owned(obj) {
+			renamed.Insert(meta.Name)
+		}
+	}
+
+	created := []runtime.Object{}
+	for _, obj := range objects {
+		isOwned := owned(obj)
+		if !isOwned && o.ToNamespace == o.Project {
+			continue
+		}
+		if isOwned {
+			renameCluster(obj, o.Name, o.Destination, renamed)
+		}
+		if dc, ok := obj.(*deployapi.DeploymentConfig); ok && dc.Labels[typeLabel] == workerType && o.Workers >= 0 {
+			dc.Spec.Replicas = o.Workers
+		}
+
+		err := createClusterObject(o.Client, o.KClient, o.ToNamespace, obj)
+		if kapierrors.IsAlreadyExists(err) && !isOwned {
+			continue
+		}
+		if err != nil {
+			return o.rollbackClone(err, created)
+		}
+		created = append(created, obj)
+	}
+
+	fmt.Fprintf(o.Out, "cluster %q created from %q in project %q\n", o.Destination, o.Name, o.ToNamespace)
+	return nil
+}
@@ -156,8 +156,7 @@ func toint64ptr(val int64) *int64 {
 }
 
 func sparkMasterURL(name string, port *kapi.ServicePort) string {
-	return "spark://" + name + ":"
-	//+ strconv.Itoa(port.Port)
+	return fmt.Sprintf("spark://%s:%d", name, port.Port)
 }
 
 func countWorkers(client kclient.PodInterface, clustername string) (int64, *kapi.PodList, error) {
@@ -176,13 +175,17 @@ func countWorkers(client kclient.PodInterface, clustername string) (int64, *kapi
 func retrieveMasterURL(client kclient.ServiceInterface, clustername string) string {
 	selectorlist := makeSelector(masterType, clustername)
 	srvs, err := client.List(selectorlist)
-	if err == nil && len(srvs.Items) != 0 {
-		srv := srvs.Items[0]
-		return sparkMasterURL(srv.Name, &srv.Spec.Ports[0])
+	if err != nil {
+		return ""
+	}
+	for i := range srvs.Items {
+		if port := servicePort(&srvs.Items[i], masterPort, masterPortName); port != nil {
+			return sparkMasterURL(srvs.Items[i].Name, port)
+		}
 	}
 	return ""
 }
-func getClusters(kClient *kclient.Client, namespace string) ([]*clusters.ClustersItems0, error) {
+func getClusters(oClient *client.Client, kClient *kclient.Client, namespace string) ([]*clusters.ClustersItems0, error) {
 	//fmt.Println("-------")
 	//fmt.Println(namespace)
 	pc := kClient.Pods(namespace)
@@ -226,6 +229,25 @@ func getClusters(kClient *kclient.Client, namespace string) ([]*clusters.Cluster
 			payload.Clusters = append(payload.Clusters, citem)
 		}
 	}
+
+	// Idled clusters have no master pod, find them from their deployment configs
+	dcs, err := oClient.DeploymentConfigs(namespace).List(makeSelector(masterType, ""))
+	if err == nil {
+		for i := range dcs.Items {
+			clustername := dcs.Items[i].Labels[clusterLabel]
+			if _, ok := clist[clustername]; ok || !isIdled(&dcs.Items[i]) {
+				continue
+			}
+			citem := new(clusters.ClustersItems0)
+			clist[clustername] = citem
+			citem.Name = tostrptr(clustername)
+			citem.Href = tostrptr("/clusters/" + clustername)
+			citem.WorkerCount = toint64ptr(0)
+			citem.Status = tostrptr(idledStatus)
+			citem.MasterURL = tostrptr(retrieveMasterURL(sc, clustername))
+			payload.Clusters = append(payload.Clusters, citem)
+		}
+	}
 	//projects, err := oClient.Projects().List(kapi.ListOptions{})
 	//if err != nil {
 	//	return nil, err
@@ -258,7 +280,7 @@ func (o ClusterOptions) RunClusters() error {
 	defaultContextName := cliconfig.GetContextNickname(currentContext.Namespace, currentContext.Cluster, currentContext.AuthInfo)
 
 	var msg string
-	clusters, err := getClusters(kclient, currentProject)
+	clusters, err := getClusters(oclient, kclient, currentProject)
 	if err == nil {
 		clusterCount := len(clusters)
 		if clusterCount <= 0 {
@@ -272,6 +294,7 @@ func (o ClusterOptions) RunClusters() error {
 				count = count + 1
 				displayName := *(cluster.Name)
 				workCount := *(cluster.WorkerCount)
+				status := *(cluster.Status)
 				//fmt.Println(displayName)
 				linebreak := "\n"
 				//if len(displayName) == 0 {
@@ -292,7 +315,7 @@ func (o ClusterOptions) RunClusters() error {
 				//	}
 				//	msg += fmt.Sprintf(linebreak+asterisk+"%s", cluster.Name)
 				//}
-				msg += fmt.Sprintf(linebreak+asterisk+"%s \t  %d", displayName, workCount)
+				msg += fmt.Sprintf(linebreak+asterisk+"%s \t  %d \t  %s", displayName, workCount, status)
 			}
 		}
 		//switch len(clusters) {
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	kcmd "k8s.io/kubernetes/pkg/kubectl/cmd"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/cmd/cli/cmd/rsync"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+)
+
+const (
+	cpLong = `
+Copy local files into the running pods of a cluster.
+
+The local file or directory is copied into the destination directory of the
+master pod, and with --all-workers of every worker pod as well, so spark
+options such as --jars local:///path or --py-files can refer to it without
+rebuilding the cluster images. The images must contain tar.
+
+As with rsync, a directory is copied into the destination directory under its
+own name, a trailing / copies its content instead. The destination directory
+is created when missing.
+
+The files are not part of the images, pods created after the copy, when a
+cluster is scaled up or a pod restarts, do not have them and the copy must be
+run again.`
+
+	cpExample = `  # Copy a jar into the master of the cluster 'mycluster'
+  %[1]s cp ./lib/deps.jar mycluster:/tmp/jars
+
+  # Copy the content of ./pylibs into /tmp/pylibs of every pod of the cluster 'mycluster'
+  %[1]s cp ./pylibs/ mycluster:/tmp/pylibs --all-workers`
+)
+
+// runningPods returns the running pods of a role of a cluster
+func runningPods(kClient *kclient.Client, namespace, clustername, otype string) ([]kapi.Pod, error) {
+	pods, err := kClient.Pods(namespace).List(makeSelector(otype, clustername))
+	if err != nil {
+		return nil, err
+	}
+	running := []kapi.Pod{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == kapi.PodRunning && pod.DeletionTimestamp == nil {
+			running = append(running, pod)
+		}
+	}
+	return running, nil
+}
+
+type CpOptions struct {
+	ClusterCmdOptions
+
+	Source      string
+	Destination string
+	AllWorkers  bool
+
+	factory *clientcmd.Factory
+	errOut  io.Writer
+}
+
+// NewCmdCp implements the oshinko cp command
+func NewCmdCp(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &CpOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "cp <LOCAL PATH> <NAME>:<DIRECTORY>",
+		Short:   "Copy local files into the pods of a cluster",
+		Long:    cpLong,
+		Example: fmt.Sprintf(cpExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, cmd, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunCp(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.AllWorkers, "all-workers", false, "If true, copy into every worker pod as well as the master pod")
+	return cmd
+}
+
+func (o *CpOptions) Complete(f *clientcmd.Factory, cmd *cobra.Command, args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("a local path and a destination <NAME>:<DIRECTORY> must be specified")
+	}
+	o.Source = args[0]
+	parts := strings.SplitN(args[1], ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("the destination must be <NAME>:<DIRECTORY>, not %q", args[1])
+	}
+	o.Destination = parts[1]
+	o.factory = f
+	o.errOut = cmd.Out()
+	return o.ClusterCmdOptions.Complete(f, parts[:1], out)
+}
+
+// copyToPod creates the destination directory in a pod and copies the source
+// into it with the tar strategy of rsync, the rsync strategy needs an rsh
+// command oshinko does not have
+func (o *CpOptions) copyToPod(config *kcmd.ExecOptions, podname string) error {
+	errOut := &bytes.Buffer{}
+	exec := *config
+	exec.PodName = podname
+	exec.Out, exec.Err = o.Out, errOut
+	if err := exec.Run(); err != nil {
+		return fmt.Errorf("unable to create the directory %s: %v %s", o.Destination, err, strings.TrimSpace(errOut.String()))
+	}
+
+	sync := &rsync.RsyncOptions{
+		StrategyName: "tar",
+		Quiet:        true,
+		Out:          o.Out,
+		ErrOut:       o.errOut,
+	}
+	if err := sync.Complete(o.factory, &cobra.Command{}, []string{o.Source, podname + ":" + o.Destination}); err != nil {
+		return err
+	}
+	if err := sync.Validate(); err != nil {
+		return err
+	}
+	return sync.RunRsync()
+}
+
+func (o *CpOptions) RunCp() error {
+	if _, _, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name); err != nil {
+		return err
+	}
+	pods, err := runningPods(o.KClient, o.Project, o.Name, masterType)
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return fmt.Errorf("no running master pod found for cluster %q", o.Name)
+	}
+	if o.AllWorkers {
+		workers, err := runningPods(o.KClient, o.Project, o.Name, workerType)
+		if err != nil {
+			return err
+		}
+		pods = append(pods, workers...)
+	}
+
+	clientConfig, err := o.factory.ClientConfig()
+	if err != nil {
+		return err
+	}
+	config := &kcmd.ExecOptions{
+		Namespace: o.Project,
+		Command:   []string{"mkdir", "-p", o.Destination},
+		Executor:  &kcmd.DefaultRemoteExecutor{},
+		Client:    o.KClient,
+		Config:    clientConfig,
+	}
+
+	failed := []string{}
+	for _, pod := range pods {
+		if err := o.copyToPod(config, pod.Name); err != nil {
+			fmt.Fprintf(o.errOut, "copy into pod %s failed: %v\n", pod.Name, err)
+			failed = append(failed, pod.Name)
+			continue
+		}
+		fmt.Fprintf(o.Out, "%s copied into pod %s:%s\n", o.Source, pod.Name, o.Destination)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("copy into %d of %d pods of cluster %q failed: %s", len(failed), len(pods), o.Name, strings.Join(failed, ", "))
+	}
+	return nil
+}
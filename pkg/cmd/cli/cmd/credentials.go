@@ -0,0 +1,417 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/util/sets"
+	"k8s.io/kubernetes/pkg/util/validation"
+
+	ocutil "github.com/openshift/origin/pkg/cmd/util"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	credentialsLong = `
+Manage the credentials and hadoop configuration of a cluster.`
+
+	credentialsAttachLong = `
+Make credentials available to the master and the workers of a cluster.
+
+A secret holding a core-site.xml is mounted as a file and HADOOP_CONF_DIR
+points at it. Any other secret is exposed as environment variables named after
+its keys, for example AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY which s3a
+reads. Use --as to choose explicitly.
+
+--hadoop-conf copies the files of a local hadoop configuration directory into
+a secret <NAME>-hadoop-conf which is mounted as HADOOP_CONF_DIR.`
+
+	credentialsAttachExample = `  # Expose the keys of the secret 's3-creds' to the cluster 'mycluster'
+  %[1]s credentials attach mycluster --secret s3-creds
+
+  # Use the local hadoop configuration of /etc/hadoop/conf
+  %[1]s credentials attach mycluster --hadoop-conf /etc/hadoop/conf`
+
+	credentialsDetachLong = `
+Remove credentials attached to a cluster.
+
+Without --secret every attached secret is removed. Secrets created from
+--hadoop-conf are deleted, other secrets are left in place.`
+
+	credentialsDetachExample = `  # Remove the secret 's3-creds' from the cluster 'mycluster'
+  %[1]s credentials detach mycluster --secret s3-creds`
+)
+
+const (
+	// credentialsAnnotation lists the secrets attached to a deployment config
+	credentialsAnnotation = "oshinko-credentials"
+
+	credentialsMountPrefix = "/etc/oshinko-credentials/"
+	hadoopConfDirEnv       = "HADOOP_CONF_DIR"
+	coreSiteFile           = "core-site.xml"
+
+	credentialsAsEnv  = "env"
+	credentialsAsFile = "file"
+)
+
+func hadoopConfSecretName(clustername string) string {
+	return clustername + "-hadoop-conf"
+}
+
+func credentialsVolumeName(secret string) string {
+	return "credentials-" + secret
+}
+
+// attachedCredentials returns the secrets attached to a deployment config
+func attachedCredentials(dc *deployapi.DeploymentConfig) sets.String {
+	attached := sets.NewString()
+	for _, name := range strings.Split(dc.Annotations[credentialsAnnotation], ",") {
+		if name != "" {
+			attached.Insert(name)
+		}
+	}
+	return attached
+}
+
+func setAttachedCredentials(dc *deployapi.DeploymentConfig, attached sets.String) {
+	if attached.Len() == 0 {
+		delete(dc.Annotations, credentialsAnnotation)
+		return
+	}
+	if dc.Annotations == nil {
+		dc.Annotations = make(map[string]string)
+	}
+	dc.Annotations[credentialsAnnotation] = strings.Join(attached.List(), ",")
+}
+
+// attachSecret exposes a secret in the spark container of a pod template
+func attachSecret(template *kapi.PodTemplateSpec, secret *kapi.Secret, as string) {
+	c := sparkContainer(template)
+	if as == credentialsAsFile {
+		setVolume(template, kapi.Volume{
+			Name: credentialsVolumeName(secret.Name),
+			VolumeSource: kapi.VolumeSource{
+				Secret: &kapi.SecretVolumeSource{SecretName: secret.Name},
+			},
+		}, credentialsMountPrefix+secret.Name)
+		setEnv(c, hadoopConfDirEnv, credentialsMountPrefix+secret.Name)
+		return
+	}
+	for _, key := range sets.StringKeySet(secret.Data).List() {
+		env := []kapi.EnvVar{}
+		for _, e := range c.Env {
+			if e.Name != key {
+				env = append(env, e)
+			}
+		}
+		c.Env = append(env, kapi.EnvVar{
+			Name: key,
+			ValueFrom: &kapi.EnvVarSource{
+				SecretKeyRef: &kapi.SecretKeySelector{
+					LocalObjectReference: kapi.LocalObjectReference{Name: secret.Name},
+					Key:                  key,
+				},
+			},
+		})
+	}
+}
+
+// attachedAsFile reports whether a secret is mounted as a file in a pod template
+func attachedAsFile(template *kapi.PodTemplateSpec, secret string) bool {
+	for _, v := range template.Spec.Volumes {
+		if v.Name == credentialsVolumeName(secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// detachSecret removes every reference to a secret from the spark container of a pod template
+func detachSecret(template *kapi.PodTemplateSpec, secret string) {
+	removeVolume(template, credentialsVolumeName(secret))
+	c := sparkContainer(template)
+	env := []kapi.EnvVar{}
+	for _, e := range c.Env {
+		if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil && e.ValueFrom.SecretKeyRef.Name == secret {
+			continue
+		}
+		if e.Name == hadoopConfDirEnv && e.Value == credentialsMountPrefix+secret {
+			continue
+		}
+		env = append(env, e)
+	}
+	c.Env = env
+}
+
+// NewCmdCredentials implements the oshinko credentials command
+func NewCmdCredentials(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "credentials",
+		Short: "Manage the credentials of a cluster",
+		Long:  credentialsLong,
+		Run:   ocutil.DefaultSubCommandRun(out),
+	}
+	cmd.AddCommand(NewCmdCredentialsAttach(fullName, f, out))
+	cmd.AddCommand(NewCmdCredentialsDetach(fullName, f, out))
+	return cmd
+}
+
+type CredentialsOptions struct {
+	ClusterCmdOptions
+
+	Secrets    []string
+	HadoopConf string
+	As         string
+}
+
+// NewCmdCredentialsAttach implements the oshinko credentials attach command
+func NewCmdCredentialsAttach(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &CredentialsOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "attach <NAME> (--secret <SECRET> | --hadoop-conf <DIR>)",
+		Short:   "Attach a secret or a hadoop configuration to a cluster",
+		Long:    credentialsAttachLong,
+		Example: fmt.Sprintf(credentialsAttachExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunCredentialsAttach(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&options.Secrets, "secret", []string{}, "The secret to attach, may be repeated")
+	cmd.Flags().StringVar(&options.HadoopConf, "hadoop-conf", "", "A local hadoop configuration directory to attach")
+	cmd.Flags().StringVar(&options.As, "as", "", "Expose secrets as env or file, by default file for secrets holding a core-site.xml")
+	return cmd
+}
+
+// NewCmdCredentialsDetach implements the oshinko credentials detach command
+func NewCmdCredentialsDetach(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &CredentialsOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "detach <NAME>",
+		Short:   "Remove credentials from a cluster",
+		Long:    credentialsDetachLong,
+		Example: fmt.Sprintf(credentialsDetachExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.ClusterCmdOptions.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunCredentialsDetach(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&options.Secrets, "secret", []string{}, "The secret to detach, may be repeated, defaults to all")
+	return cmd
+}
+
+func (o *CredentialsOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if err := o.ClusterCmdOptions.Complete(f, args, out); err != nil {
+		return err
+	}
+	if len(o.Secrets) == 0 && o.HadoopConf == "" {
+		return fmt.Errorf("--secret or --hadoop-conf must be specified")
+	}
+	if o.As != "" && o.As != credentialsAsEnv && o.As != credentialsAsFile {
+		return fmt.Errorf("--as must be %s or %s", credentialsAsEnv, credentialsAsFile)
+	}
+	return nil
+}
+
+// hadoopConfSecret builds a secret from the files of a local directory
+func (o *CredentialsOptions) hadoopConfSecret() (*kapi.Secret, error) {
+	files, err := ioutil.ReadDir(o.HadoopConf)
+	if err != nil {
+		return nil, err
+	}
+	secret := &kapi.Secret{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   hadoopConfSecretName(o.Name),
+			Labels: map[string]string{clusterLabel: o.Name},
+		},
+		Data: map[string][]byte{},
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(o.HadoopConf, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		secret.Data[file.Name()] = data
+	}
+	if len(secret.Data) == 0 {
+		return nil, fmt.Errorf("no files found in %s", o.HadoopConf)
+	}
+	return secret, nil
+}
+
+func (o *CredentialsOptions) RunCredentialsAttach() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc != nil && sparkContainer(dc.Spec.Template) == nil {
+			return fmt.Errorf("deployment config %s has no containers", dc.Name)
+		}
+	}
+
+	// check every secret before changing anything
+	type attachment struct {
+		secret *kapi.Secret
+		as     string
+	}
+	attachments := []attachment{}
+	asFile := 0
+	for _, name := range o.Secrets {
+		secret, err := o.KClient.Secrets(o.Project).Get(name)
+		if err != nil {
+			return err
+		}
+		as := o.As
+		if as == "" {
+			as = credentialsAsEnv
+			if _, ok := secret.Data[coreSiteFile]; ok {
+				as = credentialsAsFile
+			}
+		}
+		if as == credentialsAsEnv {
+			for key := range secret.Data {
+				if !validation.IsCIdentifier(key) {
+					return fmt.Errorf("key %q of secret %s is not a valid environment variable name, use --as file", key, name)
+				}
+			}
+		} else {
+			asFile++
+		}
+		attachments = append(attachments, attachment{secret, as})
+	}
+	if o.HadoopConf != "" {
+		asFile++
+	}
+	// secrets attached as files before count too, unless they are attached again now
+	again := sets.NewString(o.Secrets...)
+	if o.HadoopConf != "" {
+		again.Insert(hadoopConfSecretName(o.Name))
+	}
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil || asFile == 0 {
+			continue
+		}
+		for _, name := range attachedCredentials(dc).Difference(again).List() {
+			if attachedAsFile(dc.Spec.Template, name) {
+				return fmt.Errorf("secret %s is already attached to cluster %q as a file, HADOOP_CONF_DIR points at a single directory; detach it first", name, o.Name)
+			}
+		}
+	}
+	if asFile > 1 {
+		return fmt.Errorf("only one hadoop configuration can be attached as a file, HADOOP_CONF_DIR points at a single directory")
+	}
+
+	if o.HadoopConf != "" {
+		secret, err := o.hadoopConfSecret()
+		if err != nil {
+			return err
+		}
+		if existing, err := o.KClient.Secrets(o.Project).Get(secret.Name); err == nil {
+			existing.Data = secret.Data
+			secret, err = o.KClient.Secrets(o.Project).Update(existing)
+		} else {
+			secret, err = o.KClient.Secrets(o.Project).Create(secret)
+		}
+		if err != nil {
+			return err
+		}
+		attachments = append(attachments, attachment{secret, credentialsAsFile})
+	}
+
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil {
+			continue
+		}
+		attached := attachedCredentials(dc)
+		for _, a := range attachments {
+			// a secret attached before may change between env and file
+			detachSecret(dc.Spec.Template, a.secret.Name)
+			attachSecret(dc.Spec.Template, a.secret, a.as)
+			attached.Insert(a.secret.Name)
+		}
+		setAttachedCredentials(dc, attached)
+		if _, err := updateAndDeploy(o.Client, o.Project, dc); err != nil {
+			return err
+		}
+	}
+	for _, a := range attachments {
+		fmt.Fprintf(o.Out, "secret %s attached to cluster %q as %s\n", a.secret.Name, o.Name, a.as)
+	}
+	return nil
+}
+
+func (o *CredentialsOptions) RunCredentialsDetach() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+
+	detached := sets.NewString()
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil || sparkContainer(dc.Spec.Template) == nil {
+			continue
+		}
+		attached := attachedCredentials(dc)
+		names := o.Secrets
+		if len(names) == 0 {
+			names = attached.List()
+		}
+		changed := false
+		for _, name := range names {
+			if !attached.Has(name) {
+				continue
+			}
+			detached.Insert(name)
+			detachSecret(dc.Spec.Template, name)
+			attached.Delete(name)
+			changed = true
+		}
+		// a deployment config without any of the secrets is left alone
+		// rather than redeployed for nothing
+		if !changed {
+			continue
+		}
+		setAttachedCredentials(dc, attached)
+		if _, err := updateAndDeploy(o.Client, o.Project, dc); err != nil {
+			return err
+		}
+	}
+
+	if detached.Has(hadoopConfSecretName(o.Name)) {
+		if err := o.KClient.Secrets(o.Project).Delete(hadoopConfSecretName(o.Name)); err != nil {
+			return err
+		}
+	}
+	if detached.Len() == 0 {
+		fmt.Fprintf(o.Out, "cluster %q has no credentials attached\n", o.Name)
+		return nil
+	}
+	fmt.Fprintf(o.Out, "detached %s from cluster %q\n", strings.Join(detached.List(), ", "), o.Name)
+	return nil
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+)
+
+func secretEnv(secret, key string) kapi.EnvVar {
+	return kapi.EnvVar{
+		Name: key,
+		ValueFrom: &kapi.EnvVarSource{
+			SecretKeyRef: &kapi.SecretKeySelector{
+				LocalObjectReference: kapi.LocalObjectReference{Name: secret},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+func secretVolume(secret string) kapi.Volume {
+	return kapi.Volume{
+		Name:         credentialsVolumeName(secret),
+		VolumeSource: kapi.VolumeSource{Secret: &kapi.SecretVolumeSource{SecretName: secret}},
+	}
+}
+
+func secretMount(secret string) kapi.VolumeMount {
+	return kapi.VolumeMount{Name: credentialsVolumeName(secret), MountPath: credentialsMountPrefix + secret}
+}
+
+func credentialsTemplate(env []kapi.EnvVar, volumes []kapi.Volume, mounts []kapi.VolumeMount) *kapi.PodTemplateSpec {
+	return &kapi.PodTemplateSpec{
+		Spec: kapi.PodSpec{
+			Volumes:    volumes,
+			Containers: []kapi.Container{{Name: "spark", Env: env, VolumeMounts: mounts}},
+		},
+	}
+}
+
+func TestAttachSecret(t *testing.T) {
+	s3 := &kapi.Secret{
+		ObjectMeta: kapi.ObjectMeta{Name: "s3"},
+		Data:       map[string][]byte{"AWS_SECRET_ACCESS_KEY": []byte("secret"), "AWS_ACCESS_KEY_ID": []byte("id")},
+	}
+	hadoop := &kapi.Secret{
+		ObjectMeta: kapi.ObjectMeta{Name: "hadoop"},
+		Data:       map[string][]byte{coreSiteFile: []byte("<configuration/>")},
+	}
+
+	tests := []struct {
+		name     string
+		secret   *kapi.Secret
+		as       string
+		template *kapi.PodTemplateSpec
+		expected *kapi.PodTemplateSpec
+	}{
+		{
+			name:   "as env",
+			secret: s3,
+			as:     credentialsAsEnv,
+			template: credentialsTemplate([]kapi.EnvVar{
+				{Name: "AWS_ACCESS_KEY_ID", Value: "old"},
+				{Name: "SPARK_WORKER_CORES", Value: "2"},
+			}, nil, nil),
+			expected: credentialsTemplate([]kapi.EnvVar{
+				{Name: "SPARK_WORKER_CORES", Value: "2"},
+				secretEnv("s3", "AWS_ACCESS_KEY_ID"),
+				secretEnv("s3", "AWS_SECRET_ACCESS_KEY"),
+			}, nil, nil),
+		},
+		{
+			name:     "as file",
+			secret:   hadoop,
+			as:       credentialsAsFile,
+			template: credentialsTemplate(nil, nil, nil),
+			expected: credentialsTemplate(
+				[]kapi.EnvVar{{Name: hadoopConfDirEnv, Value: credentialsMountPrefix + "hadoop"}},
+				[]kapi.Volume{secretVolume("hadoop")},
+				[]kapi.VolumeMount{secretMount("hadoop")},
+			),
+		},
+	}
+
+	for _, test := range tests {
+		attachSecret(test.template, test.secret, test.as)
+		if !reflect.DeepEqual(test.template, test.expected) {
+			t.Errorf("%s: expected\n%#v\ngot\n%#v", test.name, test.expected, test.template)
+		}
+	}
+}
+
+func TestDetachSecret(t *testing.T) {
+	attached := func() *kapi.PodTemplateSpec {
+		return credentialsTemplate(
+			[]kapi.EnvVar{
+				{Name: "SPARK_WORKER_CORES", Value: "2"},
+				secretEnv("s3", "AWS_ACCESS_KEY_ID"),
+				{Name: hadoopConfDirEnv, Value: credentialsMountPrefix + "hadoop"},
+			},
+			[]kapi.Volume{secretVolume("hadoop")},
+			[]kapi.VolumeMount{secretMount("hadoop")},
+		)
+	}
+
+	tests := []struct {
+		secret   string
+		expected *kapi.PodTemplateSpec
+	}{
+		{
+			secret: "s3",
+			expected: credentialsTemplate(
+				[]kapi.EnvVar{
+					{Name: "SPARK_WORKER_CORES", Value: "2"},
+					{Name: hadoopConfDirEnv, Value: credentialsMountPrefix + "hadoop"},
+				},
+				[]kapi.Volume{secretVolume("hadoop")},
+				[]kapi.VolumeMount{secretMount("hadoop")},
+			),
+		},
+		{
+			secret: "hadoop",
+			expected: credentialsTemplate(
+				[]kapi.EnvVar{
+					{Name: "SPARK_WORKER_CORES", Value: "2"},
+					secretEnv("s3", "AWS_ACCESS_KEY_ID"),
+				},
+				[]kapi.Volume{},
+				[]kapi.VolumeMount{},
+			),
+		},
+	}
+
+	for _, test := range tests {
+		template := attached()
+		detachSecret(template, test.secret)
+		if !reflect.DeepEqual(template, test.expected) {
+			t.Errorf("%s: expected\n%#v\ngot\n%#v", test.secret, test.expected, template)
+		}
+	}
+}
+
+func TestAttachedAsFile(t *testing.T) {
+	template := credentialsTemplate(
+		[]kapi.EnvVar{secretEnv("s3", "AWS_ACCESS_KEY_ID")},
+		[]kapi.Volume{secretVolume("hadoop")},
+		[]kapi.VolumeMount{secretMount("hadoop")},
+	)
+	for secret, expected := range map[string]bool{"hadoop": true, "s3": false, "other": false} {
+		if got := attachedAsFile(template, secret); got != expected {
+			t.Errorf("%s: expected %v, got %v", secret, expected, got)
+		}
+	}
+}
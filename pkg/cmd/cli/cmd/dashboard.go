@@ -0,0 +1,536 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	dockerterm "github.com/docker/docker/pkg/term"
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	kterm "k8s.io/kubernetes/pkg/util/term"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	dashboardLong = `
+Show a continuously updating view of the clusters in the project.
+
+Pods and deployment configs are watched, so changes show up as they happen.
+The state of each spark master is refreshed every %v. Keys:
+
+  up/down or k/j   select a cluster, a pod
+  enter            show the pods and applications of the selected cluster
+  l                show the log of the selected pod
+  esc or b         go back
+  q                quit`
+
+	dashboardExample = `  # Watch the clusters of the current project
+  %[1]s dashboard`
+)
+
+const (
+	masterStateInterval = 5 * time.Second
+	watchRetryInterval  = 2 * time.Second
+	dashboardLogLines   = 200
+)
+
+const (
+	clustersView = iota
+	clusterView
+	logsView
+)
+
+// dashboard holds what is shown and the position of the user in the views
+type dashboard struct {
+	o *DashboardOptions
+
+	lock   sync.Mutex
+	pods   map[string]*kapi.Pod
+	dcs    map[string]*deployapi.DeploymentConfig
+	states map[string]*sparkMasterState
+	err    error
+
+	view     int
+	selected int
+	pod      int
+	cluster  string
+	logs     string
+	changed  chan struct{}
+	terminal uintptr
+}
+
+// notify asks for a redraw without blocking the watches
+func (d *dashboard) notify() {
+	select {
+	case d.changed <- struct{}{}:
+	default:
+	}
+}
+
+// listAndWatch keeps a view of objects up to date: it lists them, then
+// applies watch events until the watch ends, then starts over
+func (d *dashboard) listAndWatch(stop <-chan struct{}, list func() (string, error), start func(string) (watch.Interface, error), apply func(watch.Event)) {
+	for {
+		version, err := list()
+		if err == nil {
+			var w watch.Interface
+			if w, err = start(version); err == nil {
+				d.lock.Lock()
+				d.err = nil
+				d.lock.Unlock()
+				d.notify()
+				d.consume(stop, w, apply)
+			}
+		}
+		d.lock.Lock()
+		d.err = err
+		d.lock.Unlock()
+		d.notify()
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(watchRetryInterval):
+		}
+	}
+}
+
+func (d *dashboard) consume(stop <-chan struct{}, w watch.Interface, apply func(watch.Event)) {
+	defer w.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case event, ok := <-w.ResultChan():
+			if !ok || event.Type == watch.Error {
+				return
+			}
+			d.lock.Lock()
+			apply(event)
+			d.lock.Unlock()
+			d.notify()
+		}
+	}
+}
+
+func (d *dashboard) watchPods(stop <-chan struct{}) {
+	d.listAndWatch(stop,
+		func() (string, error) {
+			pods, err := d.o.KClient.Pods(d.o.Project).List(makeSelector("", ""))
+			if err != nil {
+				return "", err
+			}
+			d.lock.Lock()
+			d.pods = map[string]*kapi.Pod{}
+			for i := range pods.Items {
+				d.pods[pods.Items[i].Name] = &pods.Items[i]
+			}
+			d.lock.Unlock()
+			return pods.ResourceVersion, nil
+		},
+		func(version string) (watch.Interface, error) {
+			opts := makeSelector("", "")
+			opts.ResourceVersion = version
+			return d.o.KClient.Pods(d.o.Project).Watch(opts)
+		},
+		func(event watch.Event) {
+			pod, ok := event.Object.(*kapi.Pod)
+			if !ok {
+				return
+			}
+			if event.Type == watch.Deleted {
+				delete(d.pods, pod.Name)
+			} else {
+				d.pods[pod.Name] = pod
+			}
+		})
+}
+
+func (d *dashboard) watchDeploymentConfigs(stop <-chan struct{}) {
+	d.listAndWatch(stop,
+		func() (string, error) {
+			dcs, err := d.o.Client.DeploymentConfigs(d.o.Project).List(makeSelector("", ""))
+			if err != nil {
+				return "", err
+			}
+			d.lock.Lock()
+			d.dcs = map[string]*deployapi.DeploymentConfig{}
+			for i := range dcs.Items {
+				d.dcs[dcs.Items[i].Name] = &dcs.Items[i]
+			}
+			d.lock.Unlock()
+			return dcs.ResourceVersion, nil
+		},
+		func(version string) (watch.Interface, error) {
+			opts := makeSelector("", "")
+			opts.ResourceVersion = version
+			return d.o.Client.DeploymentConfigs(d.o.Project).Watch(opts)
+		},
+		func(event watch.Event) {
+			dc, ok := event.Object.(*deployapi.DeploymentConfig)
+			if !ok {
+				return
+			}
+			if event.Type == watch.Deleted {
+				delete(d.dcs, dc.Name)
+			} else {
+				d.dcs[dc.Name] = dc
+			}
+		})
+}
+
+// refreshMasterStates reads the state of every running master, the only
+// information which cannot be watched
+func (d *dashboard) refreshMasterStates(stop <-chan struct{}) {
+	ticker := time.NewTicker(masterStateInterval)
+	defer ticker.Stop()
+	for {
+		d.lock.Lock()
+		clusters := []string{}
+		for _, pod := range d.pods {
+			if pod.Labels[typeLabel] == masterType && pod.Status.Phase == kapi.PodRunning {
+				clusters = append(clusters, pod.Labels[clusterLabel])
+			}
+		}
+		d.lock.Unlock()
+
+		states := map[string]*sparkMasterState{}
+		for _, name := range clusters {
+			if state, err := getMasterState(d.o.KClient, d.o.Project, name); err == nil {
+				states[name] = state
+			}
+		}
+		d.lock.Lock()
+		d.states = states
+		d.lock.Unlock()
+		d.notify()
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// clusterNames returns the sorted names of the clusters with a master or worker
+func (d *dashboard) clusterNames() []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, dc := range d.dcs {
+		name := dc.Labels[clusterLabel]
+		otype := dc.Labels[typeLabel]
+		if !seen[name] && (otype == masterType || otype == workerType) {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clusterPods returns the pods of a cluster sorted by role and name
+func (d *dashboard) clusterPods(cluster string) []*kapi.Pod {
+	pods := []*kapi.Pod{}
+	for _, pod := range d.pods {
+		if pod.Labels[clusterLabel] == cluster {
+			pods = append(pods, pod)
+		}
+	}
+	sort.Sort(podsByRole(pods))
+	return pods
+}
+
+type podsByRole []*kapi.Pod
+
+func (p podsByRole) Len() int      { return len(p) }
+func (p podsByRole) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p podsByRole) Less(i, j int) bool {
+	if p[i].Labels[typeLabel] != p[j].Labels[typeLabel] {
+		return p[i].Labels[typeLabel] < p[j].Labels[typeLabel]
+	}
+	return p[i].Name < p[j].Name
+}
+
+func (d *dashboard) clusterStatus(cluster string) string {
+	var master, worker *deployapi.DeploymentConfig
+	for _, dc := range d.dcs {
+		if dc.Labels[clusterLabel] != cluster {
+			continue
+		}
+		switch dc.Labels[typeLabel] {
+		case masterType:
+			master = dc
+		case workerType:
+			worker = dc
+		}
+	}
+	if (master != nil && isIdled(master)) || (worker != nil && isIdled(worker)) {
+		return idledStatus
+	}
+	if _, ok := d.states[cluster]; ok {
+		return "Running"
+	}
+	return "Starting"
+}
+
+func (d *dashboard) desiredWorkers(cluster string) int {
+	for _, dc := range d.dcs {
+		if dc.Labels[clusterLabel] == cluster && dc.Labels[typeLabel] == workerType {
+			return dc.Spec.Replicas
+		}
+	}
+	return 0
+}
+
+func (d *dashboard) renderClusters(w io.Writer) {
+	names := d.clusterNames()
+	if d.selected >= len(names) {
+		d.selected = len(names) - 1
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(w, "There are no clusters in this project.")
+		return
+	}
+	fmt.Fprintln(w, "  CLUSTER\tSTATUS\tWORKERS\tCORES\tMEMORY\tAPPS")
+	for i, name := range names {
+		marker := " "
+		if i == d.selected {
+			marker = ">"
+		}
+		workers, cores, memory, apps := "-", "-", "-", "-"
+		desired := d.desiredWorkers(name)
+		if state, ok := d.states[name]; ok {
+			workers = fmt.Sprintf("%d/%d", state.AliveWorkers(), desired)
+			cores = fmt.Sprintf("%d/%d", state.CoresUsed, state.Cores)
+			memory = fmt.Sprintf("%dM/%dM", state.MemoryUsed, state.Memory)
+			apps = fmt.Sprintf("%d", len(state.ActiveApps))
+		} else {
+			workers = fmt.Sprintf("-/%d", desired)
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%s\t%s\n", marker, name, d.clusterStatus(name), workers, cores, memory, apps)
+	}
+}
+
+func (d *dashboard) renderCluster(w io.Writer) {
+	pods := d.clusterPods(d.cluster)
+	if d.pod >= len(pods) {
+		d.pod = len(pods) - 1
+	}
+	fmt.Fprintf(w, "Cluster %s\t%s\n\n", d.cluster, d.clusterStatus(d.cluster))
+	fmt.Fprintln(w, "  POD\tROLE\tSTATUS\tREADY\tNODE")
+	for i, pod := range pods {
+		marker := " "
+		if i == d.pod {
+			marker = ">"
+		}
+		ready := "no"
+		if kapi.IsPodReady(pod) {
+			ready = "yes"
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%s\n", marker, pod.Name, pod.Labels[typeLabel], pod.Status.Phase, ready, pod.Spec.NodeName)
+	}
+
+	fmt.Fprintln(w)
+	state, ok := d.states[d.cluster]
+	if !ok || len(state.ActiveApps) == 0 {
+		fmt.Fprintln(w, "No running applications.")
+		return
+	}
+	fmt.Fprintln(w, "  APPLICATION\tNAME\tCORES\tUSER\tSTATE")
+	for _, app := range state.ActiveApps {
+		fmt.Fprintf(w, "  %s\t%s\t%d\t%s\t%s\n", app.ID, app.Name, app.Cores, app.User, app.State)
+	}
+}
+
+// render draws the current view on the whole screen. The terminal is raw, so
+// lines end with a carriage return too.
+func (d *dashboard) render() {
+	d.lock.Lock()
+	buf := &bytes.Buffer{}
+	w := kubectl.GetNewTabWriter(buf)
+	fmt.Fprintf(w, "Project %s\t%s\n\n", d.o.Project, time.Now().Format("15:04:05"))
+	switch d.view {
+	case clustersView:
+		d.renderClusters(w)
+	case clusterView:
+		d.renderCluster(w)
+	case logsView:
+		fmt.Fprintln(w, d.logs)
+	}
+	if d.err != nil {
+		fmt.Fprintf(w, "\nerror: %v\n", d.err)
+	}
+	w.Flush()
+	d.lock.Unlock()
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	height := 0
+	if ws, err := dockerterm.GetWinsize(d.terminal); err == nil && ws.Height > 0 {
+		height = int(ws.Height)
+	}
+	if height > 0 && len(lines) > height {
+		if d.view == logsView {
+			lines = lines[len(lines)-height:]
+		} else {
+			lines = lines[:height]
+		}
+	}
+	fmt.Fprint(d.o.Out, "\x1b[H\x1b[2J"+strings.Join(lines, "\r\n"))
+}
+
+// showLogs reads the end of the log of the selected pod
+func (d *dashboard) showLogs() {
+	d.lock.Lock()
+	pods := d.clusterPods(d.cluster)
+	d.lock.Unlock()
+	if d.pod < 0 || d.pod >= len(pods) {
+		return
+	}
+	lines := int64(dashboardLogLines)
+	name := pods[d.pod].Name
+	body, err := d.o.KClient.Pods(d.o.Project).GetLogs(name, &kapi.PodLogOptions{TailLines: &lines}).DoRaw()
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if err != nil {
+		d.logs = fmt.Sprintf("unable to read the log of %s: %v", name, err)
+	} else {
+		d.logs = fmt.Sprintf("Log of %s\n\n%s", name, body)
+	}
+	d.view = logsView
+}
+
+// handleKey moves through the views and reports whether to quit
+func (d *dashboard) handleKey(key string) bool {
+	switch key {
+	case "q", "\x03":
+		return true
+	case "k", "\x1b[A":
+		if d.view == clustersView && d.selected > 0 {
+			d.selected--
+		} else if d.view == clusterView && d.pod > 0 {
+			d.pod--
+		}
+	case "j", "\x1b[B":
+		if d.view == clustersView {
+			d.selected++
+		} else if d.view == clusterView {
+			d.pod++
+		}
+	case "\r", "\n":
+		if d.view == clustersView {
+			d.lock.Lock()
+			names := d.clusterNames()
+			d.lock.Unlock()
+			if d.selected >= 0 && d.selected < len(names) {
+				d.cluster = names[d.selected]
+				d.pod = 0
+				d.view = clusterView
+			}
+		}
+	case "l":
+		if d.view == clusterView {
+			d.showLogs()
+		}
+	case "\x1b", "b", "\x7f":
+		if d.view > clustersView {
+			d.view--
+		}
+	}
+	return false
+}
+
+type DashboardOptions struct {
+	ClusterCmdOptions
+}
+
+// NewCmdDashboard implements the oshinko dashboard command
+func NewCmdDashboard(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &DashboardOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "dashboard",
+		Short:   "Show a live view of the clusters",
+		Long:    fmt.Sprintf(dashboardLong, masterStateInterval),
+		Example: fmt.Sprintf(dashboardExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunDashboard(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	return cmd
+}
+
+func (o *DashboardOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if len(args) > 0 {
+		return fmt.Errorf("no arguments should be passed")
+	}
+	return o.completeClients(f, out)
+}
+
+func (o *DashboardOptions) RunDashboard() error {
+	tty := kterm.TTY{In: os.Stdin, Raw: true, TryDev: true}
+	if !tty.IsTerminal() {
+		return fmt.Errorf("the dashboard needs a terminal")
+	}
+	d := &dashboard{
+		o:        o,
+		pods:     map[string]*kapi.Pod{},
+		dcs:      map[string]*deployapi.DeploymentConfig{},
+		states:   map[string]*sparkMasterState{},
+		changed:  make(chan struct{}, 1),
+		terminal: os.Stdout.Fd(),
+	}
+
+	return tty.Safe(func() error {
+		stop := make(chan struct{})
+		defer close(stop)
+		go d.watchPods(stop)
+		go d.watchDeploymentConfigs(stop)
+		go d.refreshMasterStates(stop)
+
+		keys := make(chan string)
+		go func() {
+			buf := make([]byte, 16)
+			for {
+				n, err := os.Stdin.Read(buf)
+				if err != nil {
+					close(keys)
+					return
+				}
+				keys <- string(buf[:n])
+			}
+		}()
+
+		// clear the screen on the way out
+		defer fmt.Fprint(o.Out, "\x1b[H\x1b[2J")
+		d.render()
+		for {
+			select {
+			case <-d.changed:
+			case key, ok := <-keys:
+				if !ok || d.handleKey(key) {
+					return nil
+				}
+			}
+			d.render()
+		}
+	})
+}
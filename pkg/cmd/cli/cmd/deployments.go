@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/util/wait"
+
+	"github.com/openshift/origin/pkg/client"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+)
+
+const pollInterval = 2 * time.Second
+
+const (
+	// changedByAnnotation records the user who last changed a deployment
+	// config through oshinko. It is carried into each deployment with the
+	// encoded config.
+	changedByAnnotation = "oshinko-changed-by"
+
+	// changedVersionAnnotation records the deployment the change of
+	// changedByAnnotation was expected to create. Later deployments carry the
+	// annotations too, but were caused by someone else.
+	changedVersionAnnotation = "oshinko-changed-version"
+)
+
+// annotateChange records the current user on a deployment config about to be
+// updated, along with the version of the deployment the update creates
+func annotateChange(oClient *client.Client, dc *deployapi.DeploymentConfig, version int) {
+	me, err := whoAmI(oClient)
+	if err != nil {
+		return
+	}
+	if dc.Annotations == nil {
+		dc.Annotations = make(map[string]string)
+	}
+	dc.Annotations[changedByAnnotation] = me.Name
+	dc.Annotations[changedVersionAnnotation] = strconv.Itoa(version)
+}
+
+// changeAuthor returns the user who caused a deployment of a deployment
+// config, as recorded by annotateChange, or <unknown>
+func changeAuthor(config *deployapi.DeploymentConfig, version int) string {
+	name := config.Annotations[changedByAnnotation]
+	if name == "" || config.Annotations[changedVersionAnnotation] != strconv.Itoa(version) {
+		return "<unknown>"
+	}
+	return name
+}
+
+// updateAndDeploy stores a modified deployment config and makes sure the
+// change is rolled out, even when the config has no config change trigger.
+func updateAndDeploy(oClient *client.Client, namespace string, dc *deployapi.DeploymentConfig) (*deployapi.DeploymentConfig, error) {
+	annotateChange(oClient, dc, dc.Status.LatestVersion+1)
+	if !deployutil.HasChangeTrigger(dc) {
+		if dc.Annotations == nil {
+			dc.Annotations = make(map[string]string)
+		}
+		dc.Annotations[deployapi.DeploymentInstantiatedAnnotation] = deployapi.DeploymentInstantiatedAnnotationValue
+	}
+	return oClient.DeploymentConfigs(namespace).Update(dc)
+}
+
+// clusterPodsReady reports whether the pods of one type in a cluster all run
+// the given image and are ready, and whether there are as many as expected.
+// An empty image matches any image.
+func clusterPodsReady(kClient *kclient.Client, namespace, clustername, otype, image string, expected int) (bool, error) {
+	pods, err := kClient.Pods(namespace).List(makeSelector(otype, clustername))
+	if err != nil {
+		return false, err
+	}
+	ready := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != kapi.PodRunning || !kapi.IsPodReady(pod) {
+			continue
+		}
+		if image != "" && (len(pod.Spec.Containers) == 0 || pod.Spec.Containers[0].Image != image) {
+			return false, nil
+		}
+		ready++
+	}
+	return ready == expected, nil
+}
+
+// waitForClusterPods waits until clusterPodsReady is satisfied or the timeout expires
+func waitForClusterPods(kClient *kclient.Client, namespace, clustername, otype, image string, expected int, timeout time.Duration) error {
+	err := wait.PollImmediate(pollInterval, timeout, func() (bool, error) {
+		return clusterPodsReady(kClient, namespace, clustername, otype, image, expected)
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for %d %s pod(s) of cluster %q to be ready", expected, otype, clustername)
+	}
+	return err
+}
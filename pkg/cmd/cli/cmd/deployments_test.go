@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+func TestChangeAuthor(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		version     int
+		expected    string
+	}{
+		{name: "not changed through oshinko", version: 1, expected: "<unknown>"},
+		{
+			name:        "caused by the change",
+			annotations: map[string]string{changedByAnnotation: "alice", changedVersionAnnotation: "3"},
+			version:     3,
+			expected:    "alice",
+		},
+		{
+			name:        "later change made otherwise",
+			annotations: map[string]string{changedByAnnotation: "alice", changedVersionAnnotation: "3"},
+			version:     4,
+			expected:    "<unknown>",
+		},
+		{
+			name:        "no recorded version",
+			annotations: map[string]string{changedByAnnotation: "alice"},
+			version:     2,
+			expected:    "<unknown>",
+		},
+	}
+
+	for _, test := range tests {
+		config := &deployapi.DeploymentConfig{ObjectMeta: kapi.ObjectMeta{Annotations: test.annotations}}
+		if author := changeAuthor(config, test.version); author != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, author)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	describeLong = `
+Show the details of a cluster.
+
+The master and worker deployments, the placement of the pods, the node
+each worker runs on, the storage attached to the cluster and its history
+server are shown.`
+
+	describeExample = `  # Describe the cluster 'mycluster'
+  %[1]s describe mycluster`
+)
+
+type DescribeOptions struct {
+	ClusterCmdOptions
+}
+
+// NewCmdDescribe implements the oshinko describe command
+func NewCmdDescribe(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &DescribeOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "describe <NAME>",
+		Short:   "Show the details of a cluster",
+		Long:    describeLong,
+		Example: fmt.Sprintf(describeExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunDescribe(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	return cmd
+}
+
+// readyPods counts the running and ready pods of a list
+func readyPods(pods []kapi.Pod) int {
+	ready := 0
+	for i := range pods {
+		if pods[i].DeletionTimestamp == nil && pods[i].Status.Phase == kapi.PodRunning && kapi.IsPodReady(&pods[i]) {
+			ready++
+		}
+	}
+	return ready
+}
+
+func describeDeployment(w io.Writer, title string, dc *deployapi.DeploymentConfig, pods []kapi.Pod) {
+	if dc == nil {
+		fmt.Fprintf(w, "%s:\t<none>\n", title)
+		return
+	}
+	fmt.Fprintf(w, "%s:\t%s\n", title, dc.Name)
+	if c := sparkContainer(dc.Spec.Template); c != nil {
+		fmt.Fprintf(w, "  Image:\t%s\n", c.Image)
+	}
+	fmt.Fprintf(w, "  Replicas:\t%d desired, %d ready\n", dc.Spec.Replicas, readyPods(pods))
+}
+
+// describePlacement shows the placement settings of the pods of a cluster and
+// the number of workers running on each node
+func describePlacement(w io.Writer, master, worker *deployapi.DeploymentConfig, workerPods []kapi.Pod) {
+	dc := worker
+	if dc == nil {
+		dc = master
+	}
+	fmt.Fprintln(w, "Placement:")
+	selector := formatNodeSelector(dc.Spec.Template.Spec.NodeSelector)
+	if selector == "" {
+		selector = "<none>"
+	}
+	fmt.Fprintf(w, "  Node Selector:\t%s\n", selector)
+
+	tolerations := []string{}
+	found, err := podTolerations(dc.Spec.Template)
+	if err != nil {
+		tolerations = append(tolerations, err.Error())
+	}
+	for _, t := range found {
+		tolerations = append(tolerations, t.String())
+	}
+	if len(tolerations) == 0 {
+		tolerations = append(tolerations, "<none>")
+	}
+	fmt.Fprintf(w, "  Tolerations:\t%s\n", strings.Join(tolerations, ", "))
+
+	spread := "no"
+	if worker != nil && spreadsWorkers(worker.Spec.Template) {
+		spread = "yes"
+	}
+	fmt.Fprintf(w, "  Spread Workers:\t%s\n", spread)
+
+	perNode := map[string]int{}
+	for i := range workerPods {
+		node := workerPods[i].Spec.NodeName
+		if node == "" {
+			node = "<unscheduled>"
+		}
+		perNode[node]++
+	}
+	if len(perNode) == 0 {
+		fmt.Fprintln(w, "  Worker Nodes:\t<none>")
+		return
+	}
+	nodes := []string{}
+	for node := range perNode {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	fmt.Fprintln(w, "  Worker Nodes:")
+	for _, node := range nodes {
+		fmt.Fprintf(w, "    %s\t%d worker(s)\n", node, perNode[node])
+	}
+}
+
+func (o *DescribeOptions) RunDescribe() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	masterPods, err := o.KClient.Pods(o.Project).List(makeSelector(masterType, o.Name))
+	if err != nil {
+		return err
+	}
+	workerPods, err := o.KClient.Pods(o.Project).List(makeSelector(workerType, o.Name))
+	if err != nil {
+		return err
+	}
+
+	status := "Running"
+	if (master != nil && isIdled(master)) || (worker != nil && isIdled(worker)) {
+		status = idledStatus
+	}
+
+	w := kubectl.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	fmt.Fprintf(w, "Name:\t%s\n", o.Name)
+	fmt.Fprintf(w, "Project:\t%s\n", o.Project)
+	fmt.Fprintf(w, "Status:\t%s\n", status)
+	describeSecurity(w, master, worker)
+	describeIsolation(w, o.KClient, o.Project, o.Name)
+	describeDeployment(w, "Master", master, masterPods.Items)
+	describeDeployment(w, "Workers", worker, workerPods.Items)
+	describePlacement(w, master, worker, workerPods.Items)
+	describeStorage(w, master, worker)
+	describeHistoryServer(w, o.Client, o.Project, o.Name)
+	describeWebUI(w, o.Client, o.Project, o.Name)
+	describeLinkedApps(w, master)
+	return nil
+}
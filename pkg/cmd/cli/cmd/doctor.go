@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/labels"
+
+	"github.com/openshift/origin/pkg/client"
+	ocutil "github.com/openshift/origin/pkg/cmd/util"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	routeapi "github.com/openshift/origin/pkg/route/api"
+)
+
+const (
+	doctorLong = `
+Diagnose problems with the clusters in a project.`
+
+	orphansLong = `
+Find orphaned and half deleted cluster resources.
+
+The cluster listing is built from master pods, so a cluster which lost its
+master is not shown there. This command groups every object carrying oshinko
+labels by cluster and reports:
+
+  * clusters without a master deployment config
+  * services which select no pods
+  * workers pointing at a master service which does not exist
+  * workers cross-linked to the master service of another cluster
+
+With --fix, clusters which lost every deployment config are deleted, missing
+masters and master services are recreated from the worker configuration and
+workers are pointed back at the master service of their cluster. Workers
+cross-linked to another cluster are only pointed back when their own master
+exists, no master is created for them.`
+
+	orphansExample = `  # Report orphaned cluster resources
+  %[1]s doctor orphans
+
+  # Report and repair them
+  %[1]s doctor orphans --fix`
+)
+
+// clusterParts are the objects found for one cluster name
+type clusterParts struct {
+	Master   *deployapi.DeploymentConfig
+	Worker   *deployapi.DeploymentConfig
+	History  *deployapi.DeploymentConfig
+	Services []*kapi.Service
+	Routes   []*routeapi.Route
+}
+
+// groupClusterParts collects every object carrying the cluster label by cluster name
+func groupClusterParts(oClient *client.Client, kClient *kclient.Client, namespace string) (map[string]*clusterParts, error) {
+	parts := map[string]*clusterParts{}
+	get := func(name string) *clusterParts {
+		if _, ok := parts[name]; !ok {
+			parts[name] = &clusterParts{}
+		}
+		return parts[name]
+	}
+	all := makeSelector("", "")
+
+	dcs, err := oClient.DeploymentConfigs(namespace).List(all)
+	if err != nil {
+		return nil, err
+	}
+	for i := range dcs.Items {
+		name, ok := dcs.Items[i].Labels[clusterLabel]
+		if !ok {
+			continue
+		}
+		switch dcs.Items[i].Labels[typeLabel] {
+		case masterType:
+			get(name).Master = &dcs.Items[i]
+		case workerType:
+			get(name).Worker = &dcs.Items[i]
+		case historyType:
+			get(name).History = &dcs.Items[i]
+		}
+	}
+
+	srvs, err := kClient.Services(namespace).List(all)
+	if err != nil {
+		return nil, err
+	}
+	for i := range srvs.Items {
+		if name, ok := srvs.Items[i].Labels[clusterLabel]; ok {
+			get(name).Services = append(get(name).Services, &srvs.Items[i])
+		}
+	}
+
+	routes, err := oClient.Routes(namespace).List(all)
+	if err != nil {
+		return nil, err
+	}
+	for i := range routes.Items {
+		if name, ok := routes.Items[i].Labels[clusterLabel]; ok {
+			get(name).Routes = append(get(name).Routes, &routes.Items[i])
+		}
+	}
+	return parts, nil
+}
+
+// envValue returns the value of an environment variable of a container
+func envValue(c *kapi.Container, name string) string {
+	for _, e := range c.Env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// setEnv sets an environment variable of a container, adding it if needed
+func setEnv(c *kapi.Container, name, value string) {
+	for i := range c.Env {
+		if c.Env[i].Name == name {
+			c.Env[i].Value = value
+			return
+		}
+	}
+	c.Env = append(c.Env, kapi.EnvVar{Name: name, Value: value})
+}
+
+// removeEnv removes an environment variable from a container
+func removeEnv(c *kapi.Container, name string) {
+	env := []kapi.EnvVar{}
+	for _, e := range c.Env {
+		if e.Name != name {
+			env = append(env, e)
+		}
+	}
+	c.Env = env
+}
+
+// masterHost extracts the host from a spark master url such as spark://host:7077
+func masterHost(url string) string {
+	host := strings.TrimPrefix(url, "spark://")
+	if i := strings.Index(host, ":"); i >= 0 {
+		host = host[:i]
+	}
+	return host
+}
+
+// NewCmdDoctor implements the oshinko doctor command
+func NewCmdDoctor(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Diagnose problems with clusters",
+		Long:  doctorLong,
+		Run:   ocutil.DefaultSubCommandRun(out),
+	}
+	cmd.AddCommand(NewCmdOrphans(fullName, f, out))
+	return cmd
+}
+
+type OrphansOptions struct {
+	ClusterCmdOptions
+
+	Fix bool
+}
+
+// orphanProblem is a single problem found with a cluster and its repair
+type orphanProblem struct {
+	Cluster string
+	Problem string
+	fix     func() error
+}
+
+// NewCmdOrphans implements the oshinko doctor orphans command
+func NewCmdOrphans(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &OrphansOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "orphans",
+		Short:   "Find orphaned and half deleted cluster resources",
+		Long:    orphansLong,
+		Example: fmt.Sprintf(orphansExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunOrphans(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.Fix, "fix", false, "If true, delete or recreate the missing parts")
+	return cmd
+}
+
+func (o *OrphansOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if len(args) > 0 {
+		return fmt.Errorf("no arguments should be passed")
+	}
+	return o.completeClients(f, out)
+}
+
+// workerMaster returns the master url the workers of a cluster use and the
+// cluster owning the service it points at, empty when there is no such
+// service. owners maps the names of the services of all clusters to their cluster.
+func workerMaster(worker *deployapi.DeploymentConfig, owners map[string]string) (string, string) {
+	c := sparkContainer(worker.Spec.Template)
+	if c == nil {
+		return "", ""
+	}
+	url := envValue(c, masterAddressEnv)
+	if url == "" {
+		return "", ""
+	}
+	return url, owners[masterHost(url)]
+}
+
+// diagnose returns the problems found with a single cluster
+func (o *OrphansOptions) diagnose(name string, p *clusterParts, owners map[string]string) ([]orphanProblem, error) {
+	problems := []orphanProblem{}
+	idled := (p.Master != nil && isIdled(p.Master)) || (p.Worker != nil && isIdled(p.Worker))
+
+	if p.Master == nil && p.Worker == nil {
+		// a history server created from a claim stands alone
+		if p.History != nil {
+			return problems, nil
+		}
+		problems = append(problems, orphanProblem{name, "no deployment configs, only leftover services or routes", func() error {
+			return deleteCluster(o.Client, o.KClient, o.Project, name)
+		}})
+		return problems, nil
+	}
+
+	hasService := map[string]bool{}
+	for _, srv := range p.Services {
+		hasService[srv.Name] = true
+	}
+
+	url, owner := "", ""
+	if p.Worker != nil {
+		url, owner = workerMaster(p.Worker, owners)
+	}
+	crossLinked := owner != "" && owner != name
+
+	// workers using the master of another cluster have not lost theirs
+	if p.Master == nil && !crossLinked {
+		worker := p.Worker
+		problems = append(problems, orphanProblem{name, "missing master deployment config", func() error {
+			dc, err := newMasterDeploymentConfig(name, worker)
+			if err != nil {
+				return err
+			}
+			if _, err := o.Client.DeploymentConfigs(o.Project).Create(dc); err != nil {
+				return err
+			}
+			if !hasService[masterServiceName(name)] {
+				srv := newClusterService(masterServiceName(name), name, masterType, masterPortName, masterPort)
+				if _, err := o.KClient.Services(o.Project).Create(srv); err != nil {
+					return err
+				}
+			}
+			if !hasService[webuiServiceName(name)] {
+				srv := newClusterService(webuiServiceName(name), name, webuiType, webPortName, webPort)
+				if _, err := o.KClient.Services(o.Project).Create(srv); err != nil {
+					return err
+				}
+			}
+			return nil
+		}})
+	}
+
+	for _, srv := range p.Services {
+		if idled || p.Master == nil || len(srv.Spec.Selector) == 0 {
+			continue
+		}
+		pods, err := o.KClient.Pods(o.Project).List(kapi.ListOptions{LabelSelector: labels.SelectorFromSet(srv.Spec.Selector)})
+		if err != nil {
+			return nil, err
+		}
+		if len(pods.Items) == 0 {
+			// nothing to repair directly, the pods come back with the deployment
+			problems = append(problems, orphanProblem{name, fmt.Sprintf("service %s selects no pods", srv.Name), nil})
+		}
+	}
+
+	if url != "" && owner != name {
+		worker := p.Worker
+		repoint := func() error {
+			c := sparkContainer(worker.Spec.Template)
+			setEnv(c, masterAddressEnv, masterAddress(name))
+			_, err := updateAndDeploy(o.Client, o.Project, worker)
+			return err
+		}
+		switch {
+		case !crossLinked:
+			problems = append(problems, orphanProblem{name, fmt.Sprintf("workers point at missing master %s", url), repoint})
+		case p.Master != nil:
+			problems = append(problems, orphanProblem{name, fmt.Sprintf("workers are cross-linked to %s of cluster %q", url, owner), repoint})
+		default:
+			problems = append(problems, orphanProblem{name, fmt.Sprintf("workers are cross-linked to %s of cluster %q and have no master of their own", url, owner), nil})
+		}
+	}
+	return problems, nil
+}
+
+func (o *OrphansOptions) RunOrphans() error {
+	parts, err := groupClusterParts(o.Client, o.KClient, o.Project)
+	if err != nil {
+		return err
+	}
+	names := []string{}
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	owners := map[string]string{}
+	for name, p := range parts {
+		for _, srv := range p.Services {
+			owners[srv.Name] = name
+		}
+	}
+
+	problems := []orphanProblem{}
+	for _, name := range names {
+		found, err := o.diagnose(name, parts[name], owners)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, found...)
+	}
+
+	if len(problems) == 0 {
+		fmt.Fprintln(o.Out, "No orphaned cluster resources found.")
+		return nil
+	}
+
+	w := kubectl.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	if o.Fix {
+		fmt.Fprintln(w, "CLUSTER\tPROBLEM\tFIX")
+	} else {
+		fmt.Fprintln(w, "CLUSTER\tPROBLEM")
+	}
+	for _, p := range problems {
+		if !o.Fix {
+			fmt.Fprintf(w, "%s\t%s\n", p.Cluster, p.Problem)
+			continue
+		}
+		result := "none available"
+		if p.fix != nil {
+			result = "fixed"
+			if err := p.fix(); err != nil {
+				result = "failed: " + err.Error()
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.Cluster, p.Problem, result)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+func TestWorkerMaster(t *testing.T) {
+	owners := map[string]string{"spark": "spark", "spark-ui": "spark", "etl": "etl"}
+
+	tests := []struct {
+		name          string
+		env           []kapi.EnvVar
+		url, expected string
+	}{
+		{name: "no master address"},
+		{
+			name:     "own master",
+			env:      []kapi.EnvVar{{Name: masterAddressEnv, Value: "spark://spark:7077"}},
+			url:      "spark://spark:7077",
+			expected: "spark",
+		},
+		{
+			name:     "master of another cluster",
+			env:      []kapi.EnvVar{{Name: masterAddressEnv, Value: "spark://etl:7077"}},
+			url:      "spark://etl:7077",
+			expected: "etl",
+		},
+		{
+			name: "missing master",
+			env:  []kapi.EnvVar{{Name: masterAddressEnv, Value: "spark://gone:7077"}},
+			url:  "spark://gone:7077",
+		},
+	}
+
+	for _, test := range tests {
+		worker := &deployapi.DeploymentConfig{Spec: deployapi.DeploymentConfigSpec{
+			Template: &kapi.PodTemplateSpec{Spec: kapi.PodSpec{Containers: []kapi.Container{{Name: "spark", Env: test.env}}}},
+		}}
+		url, owner := workerMaster(worker, owners)
+		if url != test.url || owner != test.expected {
+			t.Errorf("%s: expected %q owned by %q, got %q owned by %q", test.name, test.url, test.expected, url, owner)
+		}
+	}
+}
@@ -0,0 +1,486 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/util/sets"
+
+	"github.com/openshift/origin/pkg/client"
+	ocmd "github.com/openshift/origin/pkg/cmd/cli/cmd"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	routeapi "github.com/openshift/origin/pkg/route/api"
+	templateapi "github.com/openshift/origin/pkg/template/api"
+)
+
+const (
+	exportLong = `
+Export a spark cluster so it can be recreated elsewhere.
+
+Every object carrying the cluster label is gathered and fields assigned by the
+server (status, resourceVersion, generated names, service IPs) are cleared.
+By default the result is a list of objects describing the cluster. Pass
+--as-template to write an OpenShift Template instead, with the cluster name,
+spark image and worker count as parameters. The worker count is an integer,
+it is substituted with the ${{WORKER_COUNT}} form which needs oc process from
+OpenShift 3.6 or later, older releases only substitute string values.`
+
+	exportExample = `  # Export the cluster 'mycluster' as YAML
+  %[1]s export mycluster
+
+  # Export the cluster 'mycluster' as a template and instantiate it in another project
+  %[1]s export mycluster --as-template > spark.yaml
+  oc process -f spark.yaml -v CLUSTER_NAME=copy -v WORKER_COUNT=5 | oc create -n other -f -`
+)
+
+const clusterNameParam = "CLUSTER_NAME"
+const sparkImageParam = "SPARK_IMAGE"
+const workerCountParam = "WORKER_COUNT"
+
+type ExportOptions struct {
+	ClusterCmdOptions
+
+	AsTemplate bool
+	Output     string
+}
+
+// NewCmdExport implements the oshinko export command
+func NewCmdExport(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &ExportOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "export <NAME>",
+		Short:   "Export a cluster as a reusable spec or template",
+		Long:    exportLong,
+		Example: fmt.Sprintf(exportExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunExport(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.AsTemplate, "as-template", false, "If true, write a parameterised OpenShift Template")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", "yaml", "Output format. One of: json|yaml")
+	return cmd
+}
+
+// clusterObjects returns every object in the namespace labelled as belonging
+// to the named cluster. Config maps and secrets referenced from the pod
+// templates are included as well since the cluster cannot run without them.
+func clusterObjects(oClient *client.Client, kClient *kclient.Client, namespace, clustername string) ([]runtime.Object, error) {
+	objects := []runtime.Object{}
+	selector := makeSelector("", clustername)
+
+	dcs, err := oClient.DeploymentConfigs(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(dcs.Items) == 0 {
+		return nil, fmt.Errorf("cluster %q not found", clustername)
+	}
+	configmaps := map[string]bool{}
+	secrets := map[string]bool{}
+	for i := range dcs.Items {
+		objects = append(objects, &dcs.Items[i])
+		if dcs.Items[i].Spec.Template != nil {
+			podReferences(&dcs.Items[i].Spec.Template.Spec, configmaps, secrets)
+		}
+	}
+
+	srvs, err := kClient.Services(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for i := range srvs.Items {
+		objects = append(objects, &srvs.Items[i])
+	}
+
+	routes, err := oClient.Routes(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for i := range routes.Items {
+		objects = append(objects, &routes.Items[i])
+	}
+
+	cms, err := kClient.ConfigMaps(namespace).List(kapi.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range cms.Items {
+		if configmaps[cms.Items[i].Name] || cms.Items[i].Labels[clusterLabel] == clustername {
+			objects = append(objects, &cms.Items[i])
+		}
+	}
+
+	scrts, err := kClient.Secrets(namespace).List(kapi.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range scrts.Items {
+		if secrets[scrts.Items[i].Name] || scrts.Items[i].Labels[clusterLabel] == clustername {
+			objects = append(objects, &scrts.Items[i])
+		}
+	}
+	return objects, nil
+}
+
+// podReferences records the names of config maps and secrets a pod spec
+// refers to through volumes or environment variables.
+func podReferences(spec *kapi.PodSpec, configmaps, secrets map[string]bool) {
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil {
+			configmaps[v.ConfigMap.Name] = true
+		}
+		if v.Secret != nil {
+			secrets[v.Secret.SecretName] = true
+		}
+	}
+	for _, c := range spec.Containers {
+		for _, e := range c.Env {
+			if e.ValueFrom == nil {
+				continue
+			}
+			if e.ValueFrom.ConfigMapKeyRef != nil {
+				configmaps[e.ValueFrom.ConfigMapKeyRef.Name] = true
+			}
+			if e.ValueFrom.SecretKeyRef != nil {
+				secrets[e.ValueFrom.SecretKeyRef.Name] = true
+			}
+		}
+	}
+}
+
+// clusterExporter clears the fields of cluster objects which are assigned by
+// the server so that the objects can be created again in any project.
+type clusterExporter struct{}
+
+var _ ocmd.Exporter = &clusterExporter{}
+
+func (e *clusterExporter) AddExportOptions(flags *pflag.FlagSet) {
+}
+
+func (e *clusterExporter) Export(obj runtime.Object, exact bool) error {
+	if meta, err := kapi.ObjectMetaFor(obj); err == nil {
+		meta.UID = ""
+		meta.Namespace = ""
+		meta.CreationTimestamp = unversioned.Time{}
+		meta.DeletionTimestamp = nil
+		meta.ResourceVersion = ""
+		meta.SelfLink = ""
+		meta.Generation = 0
+		if len(meta.GenerateName) > 0 {
+			meta.Name = ""
+		}
+	}
+	switch t := obj.(type) {
+	case *deployapi.DeploymentConfig:
+		t.Status = deployapi.DeploymentConfigStatus{}
+		for i := range t.Spec.Triggers {
+			if p := t.Spec.Triggers[i].ImageChangeParams; p != nil {
+				p.LastTriggeredImage = ""
+			}
+		}
+	case *kapi.Service:
+		t.Status = kapi.ServiceStatus{}
+		if t.Spec.ClusterIP != kapi.ClusterIPNone {
+			t.Spec.ClusterIP = ""
+		}
+		for i := range t.Spec.Ports {
+			t.Spec.Ports[i].NodePort = 0
+		}
+	case *routeapi.Route:
+		// the host was generated from the project name, let the router pick a new one
+		t.Spec.Host = ""
+		t.Status = routeapi.RouteStatus{}
+	case *kapi.Secret:
+		if t.Type == kapi.SecretTypeServiceAccountToken || len(t.Annotations[kapi.ServiceAccountUIDKey]) > 0 {
+			return ocmd.ErrExportOmit
+		}
+	}
+	return nil
+}
+
+// exportClusterObjects gathers and strips the objects of a cluster
+func exportClusterObjects(oClient *client.Client, kClient *kclient.Client, namespace, clustername string) ([]runtime.Object, error) {
+	objects, err := clusterObjects(oClient, kClient, namespace, clustername)
+	if err != nil {
+		return nil, err
+	}
+	exporter := &clusterExporter{}
+	result := []runtime.Object{}
+	for _, obj := range objects {
+		if err := exporter.Export(obj, false); err != nil {
+			if err == ocmd.ErrExportOmit {
+				continue
+			}
+			return nil, err
+		}
+		result = append(result, obj)
+	}
+	return result, nil
+}
+
+// renameName renames the names derived from a cluster name, such as
+// mycluster-m or mycluster-ui, and leaves other names alone
+func renameName(name, from, to string) string {
+	switch {
+	case name == from:
+		return to
+	case strings.HasPrefix(name, from+"-"):
+		return to + strings.TrimPrefix(name, from)
+	}
+	return name
+}
+
+// renameAddress renames the host of the urls pointing at the master or at
+// the web UI service of a cluster, such as spark://mycluster:7077
+func renameAddress(value, from, to string) string {
+	for _, host := range [][2]string{{from, to}, {webuiServiceName(from), webuiServiceName(to)}} {
+		value = strings.Replace(value, "//"+host[0]+":", "//"+host[1]+":", -1)
+	}
+	return value
+}
+
+// renameLabels renames the cluster label and the deployment config label of
+// the objects of a cluster, other labels such as the type are kept
+func renameLabels(labels map[string]string, from, to string) {
+	if labels[clusterLabel] == from {
+		labels[clusterLabel] = to
+	}
+	if name, ok := labels[deployapi.DeploymentConfigLabel]; ok {
+		labels[deployapi.DeploymentConfigLabel] = renameName(name, from, to)
+	}
+}
+
+// renamePodSpec renames the references of a pod to the objects of a cluster
+// which are renamed along with it, and the addresses of the cluster in its
+// environment and arguments
+func renamePodSpec(spec *kapi.PodSpec, from, to string, renamed sets.String) {
+	rename := func(name string) string {
+		if !renamed.Has(name) {
+			return name
+		}
+		return renameName(name, from, to)
+	}
+	spec.ServiceAccountName = rename(spec.ServiceAccountName)
+	for i := range spec.Volumes {
+		v := &spec.Volumes[i]
+		switch {
+		case v.ConfigMap != nil:
+			v.ConfigMap.Name = rename(v.ConfigMap.Name)
+		case v.Secret != nil:
+			v.Secret.SecretName = rename(v.Secret.SecretName)
+		case v.PersistentVolumeClaim != nil:
+			v.PersistentVolumeClaim.ClaimName = rename(v.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	for i := range spec.Containers {
+		c := &spec.Containers[i]
+		c.Name = renameName(c.Name, from, to)
+		for j := range c.Env {
+			e := &c.Env[j]
+			if e.Value == from {
+				e.Value = to
+			} else {
+				e.Value = renameAddress(e.Value, from, to)
+			}
+			if e.ValueFrom != nil && e.ValueFrom.ConfigMapKeyRef != nil {
+				e.ValueFrom.ConfigMapKeyRef.Name = rename(e.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+				e.ValueFrom.SecretKeyRef.Name = rename(e.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+		for j, arg := range c.Args {
+			// the OAuth proxy names its service account and the web UI service
+			if strings.HasPrefix(arg, serviceAccountFlag) {
+				arg = serviceAccountFlag + rename(strings.TrimPrefix(arg, serviceAccountFlag))
+			}
+			arg = strings.Replace(arg, strconv.Quote(webuiServiceName(from)), strconv.Quote(webuiServiceName(to)), -1)
+			c.Args[j] = renameAddress(arg, from, to)
+		}
+	}
+}
+
+// renameCluster rewrites the references to a cluster name in an object: the
+// names of the cluster's objects which are derived from it, the cluster label
+// in labels and selectors, and master urls such as spark://mycluster:7077 in
+// the environment. Other fields are never touched, so that a cluster named
+// after a label value or a port name keeps them intact. The config maps,
+// secrets, service accounts and claims a pod refers to are only renamed when
+// their name is in renamed, the objects renamed along with the cluster.
+func renameCluster(obj runtime.Object, from, to string, renamed sets.String) {
+	if meta, err := kapi.ObjectMetaFor(obj); err == nil {
+		meta.Name = renameName(meta.Name, from, to)
+		renameLabels(meta.Labels, from, to)
+	}
+	switch t := obj.(type) {
+	case *deployapi.DeploymentConfig:
+		// the linked applications keep using the original cluster
+		delete(t.Annotations, linkedAppsAnnotation)
+		renameLabels(t.Spec.Selector, from, to)
+		if t.Spec.Template != nil {
+			renameLabels(t.Spec.Template.Labels, from, to)
+			renamePodSpec(&t.Spec.Template.Spec, from, to, renamed)
+		}
+		for i := range t.Spec.Triggers {
+			if p := t.Spec.Triggers[i].ImageChangeParams; p != nil {
+				for j := range p.ContainerNames {
+					p.ContainerNames[j] = renameName(p.ContainerNames[j], from, to)
+				}
+			}
+		}
+	case *kapi.Service:
+		renameLabels(t.Spec.Selector, from, to)
+	case *routeapi.Route:
+		t.Spec.To.Name = renameName(t.Spec.To.Name, from, to)
+	}
+}
+
+// parameterizeWorkers replaces the replicas of a versioned worker deployment
+// config with a reference to the worker count parameter. The field is an
+// integer, so the object is turned into an unstructured one and the non string
+// ${{}} form of the reference is used.
+func parameterizeWorkers(obj runtime.Object) (runtime.Object, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	object := make(map[string]interface{})
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	spec, ok := object["spec"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("the worker deployment config has no spec")
+	}
+	spec["replicas"] = "${{" + workerCountParam + "}}"
+	return &runtime.Unstructured{Object: object}, nil
+}
+
+// parameterize replaces the cluster name and the spark image in the exported
+// objects with template parameter references and returns the parameters.
+// The worker count is replaced by parameterizeWorkers once the objects are
+// versioned.
+func parameterize(objects []runtime.Object, clustername string) []templateapi.Parameter {
+	image := ""
+	workers := 0
+	for _, obj := range objects {
+		if dc, ok := obj.(*deployapi.DeploymentConfig); ok {
+			if c := sparkContainer(dc.Spec.Template); c != nil {
+				if image == "" || dc.Labels[typeLabel] == workerType {
+					image = c.Image
+				}
+			}
+			if dc.Labels[typeLabel] == workerType {
+				workers = int(dc.Spec.Replicas)
+			}
+		}
+	}
+
+	// every exported object is renamed, so are the references to them
+	renamed := sets.NewString()
+	for _, obj := range objects {
+		if meta, err := kapi.ObjectMetaFor(obj); err == nil {
+			renamed.Insert(meta.Name)
+		}
+	}
+	for _, obj := range objects {
+		if dc, ok := obj.(*deployapi.DeploymentConfig); ok {
+			if c := sparkContainer(dc.Spec.Template); c != nil && image != "" && c.Image == image {
+				c.Image = "${" + sparkImageParam + "}"
+			}
+		}
+		renameCluster(obj, clustername, "${"+clusterNameParam+"}", renamed)
+	}
+
+	return []templateapi.Parameter{
+		{
+			Name:        clusterNameParam,
+			DisplayName: "Cluster name",
+			Description: "The name of the spark cluster",
+			Value:       clustername,
+			Required:    true,
+		},
+		{
+			Name:        sparkImageParam,
+			DisplayName: "Spark image",
+			Description: "The image used for the spark master and workers",
+			Value:       image,
+			Required:    true,
+		},
+		{
+			Name:        workerCountParam,
+			DisplayName: "Worker count",
+			Description: "The number of spark workers",
+			Value:       strconv.Itoa(workers),
+			Required:    true,
+		},
+	}
+}
+
+func (o *ExportOptions) RunExport() error {
+	objects, err := exportClusterObjects(o.Client, o.KClient, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+
+	var params []templateapi.Parameter
+	if o.AsTemplate {
+		params = parameterize(objects, o.Name)
+	}
+
+	versioned := []runtime.Object{}
+	for _, obj := range objects {
+		v, err := kapi.Scheme.ConvertToVersion(obj, "v1")
+		if err != nil {
+			return err
+		}
+		if dc, ok := obj.(*deployapi.DeploymentConfig); ok && o.AsTemplate && dc.Labels[typeLabel] == workerType {
+			if v, err = parameterizeWorkers(v); err != nil {
+				return err
+			}
+		}
+		versioned = append(versioned, v)
+	}
+
+	var result runtime.Object
+	if o.AsTemplate {
+		template := &templateapi.Template{
+			Objects:    versioned,
+			Parameters: params,
+		}
+		template.Name = o.Name
+		result, err = kapi.Scheme.ConvertToVersion(template, "v1")
+	} else {
+		result, err = kapi.Scheme.ConvertToVersion(&kapi.List{Items: versioned}, "v1")
+	}
+	if err != nil {
+		return err
+	}
+
+	p, _, err := kubectl.GetPrinter(o.Output, "")
+	if err != nil {
+		return err
+	}
+	return p.PrintObj(result, o.Out)
+}
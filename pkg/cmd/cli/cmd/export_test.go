@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/util/sets"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	routeapi "github.com/openshift/origin/pkg/route/api"
+)
+
+func testDeploymentConfig(name, clustername, otype string, container kapi.Container) *deployapi.DeploymentConfig {
+	labels := func() map[string]string {
+		l := clusterLabels(otype, clustername)
+		l[deployapi.DeploymentConfigLabel] = name
+		return l
+	}
+	return &deployapi.DeploymentConfig{
+		ObjectMeta: kapi.ObjectMeta{Name: name, Labels: labels()},
+		Spec: deployapi.DeploymentConfigSpec{
+			Selector: labels(),
+			Template: &kapi.PodTemplateSpec{
+				ObjectMeta: kapi.ObjectMeta{Labels: labels()},
+				Spec:       kapi.PodSpec{Containers: []kapi.Container{container}},
+			},
+			Triggers: []deployapi.DeploymentTriggerPolicy{{
+				Type:              deployapi.DeploymentTriggerOnImageChange,
+				ImageChangeParams: &deployapi.DeploymentTriggerImageChangeParams{ContainerNames: []string{container.Name}},
+			}},
+		},
+	}
+}
+
+func TestRenameCluster(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		renamed  []string
+		obj      runtime.Object
+		expected runtime.Object
+	}{
+		{
+			name: "worker",
+			from: "spark",
+			to:   "etl",
+			obj: testDeploymentConfig("spark-w", "spark", workerType, kapi.Container{
+				Name: "spark-w",
+				Env: []kapi.EnvVar{
+					{Name: masterAddressEnv, Value: "spark://spark:7077"},
+					{Name: masterUIAddressEnv, Value: "http://spark-ui:8080"},
+					{Name: clusterNameEnv, Value: "spark"},
+					{Name: "APP", Value: "sparky"},
+				},
+				Ports: []kapi.ContainerPort{{Name: "spark", ContainerPort: 7077}},
+			}),
+			expected: testDeploymentConfig("etl-w", "etl", workerType, kapi.Container{
+				Name: "etl-w",
+				Env: []kapi.EnvVar{
+					{Name: masterAddressEnv, Value: "spark://etl:7077"},
+					{Name: masterUIAddressEnv, Value: "http://etl-ui:8080"},
+					{Name: clusterNameEnv, Value: "etl"},
+					{Name: "APP", Value: "sparky"},
+				},
+				Ports: []kapi.ContainerPort{{Name: "spark", ContainerPort: 7077}},
+			}),
+		},
+		{
+			name: "cluster named after a type",
+			from: "master",
+			to:   "etl",
+			obj: testDeploymentConfig("master-m", "master", masterType, kapi.Container{
+				Name: "master-m",
+				Env:  []kapi.EnvVar{{Name: "ROLE", Value: "master-node"}},
+			}),
+			expected: testDeploymentConfig("etl-m", "etl", masterType, kapi.Container{
+				Name: "etl-m",
+				Env:  []kapi.EnvVar{{Name: "ROLE", Value: "master-node"}},
+			}),
+		},
+		{
+			name:    "oauth proxy",
+			from:    "openshift",
+			to:      "etl",
+			renamed: []string{"openshift-proxy"},
+			obj: testDeploymentConfig("openshift-proxy", "openshift", oauthProxyType, kapi.Container{
+				Name: "openshift-proxy",
+				Args: []string{
+					"--provider=openshift",
+					serviceAccountFlag + "openshift-proxy",
+					"--upstream=http://openshift-ui:8080",
+					`--openshift-sar={"resource":"services","name":"openshift-ui"}`,
+				},
+			}),
+			expected: testDeploymentConfig("etl-proxy", "etl", oauthProxyType, kapi.Container{
+				Name: "etl-proxy",
+				Args: []string{
+					"--provider=openshift",
+					serviceAccountFlag + "etl-proxy",
+					"--upstream=http://etl-ui:8080",
+					`--openshift-sar={"resource":"services","name":"etl-ui"}`,
+				},
+			}),
+		},
+		{
+			name:    "references",
+			from:    "spark",
+			to:      "etl",
+			renamed: []string{"spark-hadoop-conf"},
+			obj: testDeploymentConfig("spark-w", "spark", workerType, kapi.Container{
+				Name: "spark-w",
+				Env: []kapi.EnvVar{
+					{Name: "AWS_ACCESS_KEY_ID", ValueFrom: &kapi.EnvVarSource{SecretKeyRef: &kapi.SecretKeySelector{
+						LocalObjectReference: kapi.LocalObjectReference{Name: "spark-s3"},
+						Key:                  "AWS_ACCESS_KEY_ID",
+					}}},
+					{Name: "CORE_SITE", ValueFrom: &kapi.EnvVarSource{ConfigMapKeyRef: &kapi.ConfigMapKeySelector{
+						LocalObjectReference: kapi.LocalObjectReference{Name: "spark-hadoop-conf"},
+						Key:                  "core-site.xml",
+					}}},
+				},
+			}),
+			expected: testDeploymentConfig("etl-w", "etl", workerType, kapi.Container{
+				Name: "etl-w",
+				Env: []kapi.EnvVar{
+					{Name: "AWS_ACCESS_KEY_ID", ValueFrom: &kapi.EnvVarSource{SecretKeyRef: &kapi.SecretKeySelector{
+						LocalObjectReference: kapi.LocalObjectReference{Name: "spark-s3"},
+						Key:                  "AWS_ACCESS_KEY_ID",
+					}}},
+					{Name: "CORE_SITE", ValueFrom: &kapi.EnvVarSource{ConfigMapKeyRef: &kapi.ConfigMapKeySelector{
+						LocalObjectReference: kapi.LocalObjectReference{Name: "etl-hadoop-conf"},
+						Key:                  "core-site.xml",
+					}}},
+				},
+			}),
+		},
+		{
+			name: "linked master",
+			from: "spark",
+			to:   "etl",
+			obj: func() runtime.Object {
+				dc := testDeploymentConfig("spark-m", "spark", masterType, kapi.Container{Name: "spark-m"})
+				dc.Annotations = map[string]string{linkedAppsAnnotation: "myapp"}
+				return dc
+			}(),
+			expected: func() runtime.Object {
+				dc := testDeploymentConfig("etl-m", "etl", masterType, kapi.Container{Name: "etl-m"})
+				dc.Annotations = map[string]string{}
+				return dc
+			}(),
+		},
+		{
+			name: "service",
+			from: "spark",
+			to:   "etl",
+			obj: &kapi.Service{
+				ObjectMeta: kapi.ObjectMeta{Name: "spark-ui", Labels: clusterLabels(webuiType, "spark")},
+				Spec: kapi.ServiceSpec{
+					Selector: clusterLabels(masterType, "spark"),
+					Ports:    []kapi.ServicePort{{Name: "spark-webui", Port: 8080}},
+				},
+			},
+			expected: &kapi.Service{
+				ObjectMeta: kapi.ObjectMeta{Name: "etl-ui", Labels: clusterLabels(webuiType, "etl")},
+				Spec: kapi.ServiceSpec{
+					Selector: clusterLabels(masterType, "etl"),
+					Ports:    []kapi.ServicePort{{Name: "spark-webui", Port: 8080}},
+				},
+			},
+		},
+		{
+			name: "route",
+			from: "spark",
+			to:   "etl",
+			obj: &routeapi.Route{
+				ObjectMeta: kapi.ObjectMeta{Name: "spark-ui", Labels: clusterLabels(webuiType, "spark")},
+				Spec:       routeapi.RouteSpec{Host: "spark.example.com", To: kapi.ObjectReference{Kind: "Service", Name: "spark-ui"}},
+			},
+			expected: &routeapi.Route{
+				ObjectMeta: kapi.ObjectMeta{Name: "etl-ui", Labels: clusterLabels(webuiType, "etl")},
+				Spec:       routeapi.RouteSpec{Host: "spark.example.com", To: kapi.ObjectReference{Kind: "Service", Name: "etl-ui"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		renameCluster(test.obj, test.from, test.to, sets.NewString(test.renamed...))
+		if !reflect.DeepEqual(test.obj, test.expected) {
+			t.Errorf("%s: expected\n%#v\ngot\n%#v", test.name, test.expected, test.obj)
+		}
+	}
+}
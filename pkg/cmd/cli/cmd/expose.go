@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kapierrors "k8s.io/kubernetes/pkg/api/errors"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/util/intstr"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	routeapi "github.com/openshift/origin/pkg/route/api"
+)
+
+const (
+	exposeLong = `
+Expose the web UI of a cluster with a TLS route.
+
+The route is terminated at the router and plain HTTP is redirected to HTTPS.
+
+With --oauth the route points at an OpenShift OAuth proxy deployed in front of
+the spark-webui port instead of at the UI itself. Users log in with their
+OpenShift account and only those allowed to view the services of the project
+reach the UI. The proxy runs as the service account <NAME>-proxy and keeps its
+session cookies signed with a generated secret of the same name.`
+
+	exposeExample = `  # Expose the web UI of the cluster 'mycluster' to the users of the project
+  %[1]s expose mycluster --oauth
+
+  # Expose the web UI of the cluster 'mycluster' at a given host name
+  %[1]s expose mycluster --hostname spark.example.com`
+)
+
+const (
+	oauthProxyType     = "oauth-proxy"
+	oauthProxyPortName = "oauth-proxy"
+	oauthProxyPort     = 4180
+	defaultProxyImage  = "openshift/oauth-proxy:v1.0.0"
+
+	serviceAccountFlag = "--openshift-service-account="
+
+	cookieSecretKey = "session_secret"
+	cookieSecretEnv = "OAUTH_COOKIE_SECRET"
+
+	// oauthRedirectAnnotation lets the service account of the proxy redirect
+	// users back to the route after they logged in
+	oauthRedirectAnnotation = "serviceaccounts.openshift.io/oauth-redirectreference.primary"
+)
+
+func oauthProxyName(clustername string) string {
+	return clustername + "-proxy"
+}
+
+// newOAuthProxyDeploymentConfig builds an OAuth proxy letting the users
+// allowed to get the web UI service of a cluster reach it
+func newOAuthProxyDeploymentConfig(namespace, clustername, image string) *deployapi.DeploymentConfig {
+	name := oauthProxyName(clustername)
+	selector := clusterLabels(oauthProxyType, clustername)
+	selector[deployapi.DeploymentConfigLabel] = name
+
+	sar := fmt.Sprintf(`{"namespace":%q,"resource":"services","name":%q,"verb":"get"}`, namespace, webuiServiceName(clustername))
+	return &deployapi.DeploymentConfig{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   name,
+			Labels: clusterLabels(oauthProxyType, clustername),
+		},
+		Spec: deployapi.DeploymentConfigSpec{
+			Strategy: deployapi.DeploymentStrategy{Type: deployapi.DeploymentStrategyTypeRolling},
+			Triggers: []deployapi.DeploymentTriggerPolicy{
+				{Type: deployapi.DeploymentTriggerOnConfigChange},
+			},
+			Replicas: 1,
+			Selector: selector,
+			Template: &kapi.PodTemplateSpec{
+				ObjectMeta: kapi.ObjectMeta{Labels: selector},
+				Spec: kapi.PodSpec{
+					ServiceAccountName: name,
+					Containers: []kapi.Container{
+						{
+							Name:  name,
+							Image: image,
+							Args: []string{
+								"--provider=openshift",
+								fmt.Sprintf("--http-address=:%d", oauthProxyPort),
+								"--https-address=",
+								fmt.Sprintf("--upstream=http://%s:%d", webuiServiceName(clustername), webPort),
+								serviceAccountFlag + name,
+								"--openshift-sar=" + sar,
+								"--cookie-secret=$(" + cookieSecretEnv + ")",
+							},
+							Env: []kapi.EnvVar{
+								{
+									Name: cookieSecretEnv,
+									ValueFrom: &kapi.EnvVarSource{
+										SecretKeyRef: &kapi.SecretKeySelector{
+											LocalObjectReference: kapi.LocalObjectReference{Name: name},
+											Key:                  cookieSecretKey,
+										},
+									},
+								},
+							},
+							Ports: []kapi.ContainerPort{
+								{Name: oauthProxyPortName, ContainerPort: oauthProxyPort, Protocol: kapi.ProtocolTCP},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// createOAuthProxy deploys an OAuth proxy in front of the web UI of a cluster
+// and returns the name of its service. Objects left by an earlier attempt
+// which failed half way are reused, so expose can simply be run again.
+func (o *ExposeOptions) createOAuthProxy(routeName string) (string, error) {
+	name := oauthProxyName(o.Name)
+
+	token, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	secret := &kapi.Secret{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   name,
+			Labels: clusterLabels(oauthProxyType, o.Name),
+		},
+		Data: map[string][]byte{cookieSecretKey: []byte(token)},
+	}
+	if _, err := o.KClient.Secrets(o.Project).Create(secret); err != nil && !kapierrors.IsAlreadyExists(err) {
+		return "", err
+	}
+
+	sa := &kapi.ServiceAccount{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   name,
+			Labels: clusterLabels(oauthProxyType, o.Name),
+			Annotations: map[string]string{
+				oauthRedirectAnnotation: fmt.Sprintf(`{"kind":"OAuthRedirectReference","apiVersion":"v1","reference":{"kind":"Route","name":%q}}`, routeName),
+			},
+		},
+	}
+	if _, err := o.KClient.ServiceAccounts(o.Project).Create(sa); kapierrors.IsAlreadyExists(err) {
+		existing, err := o.KClient.ServiceAccounts(o.Project).Get(name)
+		if err != nil {
+			return "", err
+		}
+		if existing.Annotations == nil {
+			existing.Annotations = make(map[string]string)
+		}
+		existing.Annotations[oauthRedirectAnnotation] = sa.Annotations[oauthRedirectAnnotation]
+		if _, err := o.KClient.ServiceAccounts(o.Project).Update(existing); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	dc := newOAuthProxyDeploymentConfig(o.Project, o.Name, o.ProxyImage)
+	if _, err := o.Client.DeploymentConfigs(o.Project).Get(dc.Name); kapierrors.IsNotFound(err) {
+		if err := checkCapacity(o.KClient, o.Project, []podShape{{dc.Spec.Template, dc.Spec.Replicas}}); err != nil {
+			return "", err
+		}
+		if _, err := o.Client.DeploymentConfigs(o.Project).Create(dc); err != nil {
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	}
+
+	srv := &kapi.Service{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   name,
+			Labels: clusterLabels(oauthProxyType, o.Name),
+		},
+		Spec: kapi.ServiceSpec{
+			Selector: clusterLabels(oauthProxyType, o.Name),
+			Ports: []kapi.ServicePort{
+				{
+					Name:       oauthProxyPortName,
+					Protocol:   kapi.ProtocolTCP,
+					Port:       oauthProxyPort,
+					TargetPort: intstr.FromInt(oauthProxyPort),
+				},
+			},
+		},
+	}
+	if _, err := o.KClient.Services(o.Project).Create(srv); err != nil && !kapierrors.IsAlreadyExists(err) {
+		return "", err
+	}
+	return srv.Name, nil
+}
+
+// describeWebUI shows the route to the web UI of a cluster
+func describeWebUI(w io.Writer, oClient *client.Client, namespace, clustername string) {
+	routes, err := oClient.Routes(namespace).List(makeSelector(webuiType, clustername))
+	if err != nil || len(routes.Items) == 0 {
+		fmt.Fprintln(w, "Web UI Route:\t<none>")
+		return
+	}
+	route := routes.Items[0]
+	scheme := "http"
+	if route.Spec.TLS != nil {
+		scheme = "https"
+	}
+	access := "open"
+	if route.Spec.To.Name == oauthProxyName(clustername) {
+		access = "oauth"
+	}
+	fmt.Fprintf(w, "Web UI Route:\t%s://%s (%s)\n", scheme, route.Spec.Host, access)
+}
+
+type ExposeOptions struct {
+	ClusterCmdOptions
+
+	OAuth      bool
+	ProxyImage string
+	Hostname   string
+}
+
+// NewCmdExpose implements the oshinko expose command
+func NewCmdExpose(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &ExposeOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "expose <NAME>",
+		Short:   "Expose the web UI of a cluster with a TLS route",
+		Long:    exposeLong,
+		Example: fmt.Sprintf(exposeExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunExpose(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.OAuth, "oauth", false, "If true, require users to log in and to be allowed to view the project")
+	cmd.Flags().StringVar(&options.ProxyImage, "proxy-image", defaultProxyImage, "The image of the OAuth proxy")
+	cmd.Flags().StringVar(&options.Hostname, "hostname", "", "The host name of the route, generated if empty")
+	return cmd
+}
+
+func (o *ExposeOptions) RunExpose() error {
+	if _, _, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name); err != nil {
+		return err
+	}
+	routes, err := o.Client.Routes(o.Project).List(makeSelector(webuiType, o.Name))
+	if err != nil {
+		return err
+	}
+	if len(routes.Items) > 0 {
+		return fmt.Errorf("the web UI of cluster %q is already exposed by the route %s", o.Name, routes.Items[0].Name)
+	}
+	if _, err := o.KClient.Services(o.Project).Get(webuiServiceName(o.Name)); err != nil {
+		return fmt.Errorf("cluster %q has no web UI service: %v", o.Name, err)
+	}
+
+	routeName := webuiServiceName(o.Name)
+	target, port := webuiServiceName(o.Name), webPortName
+	if o.OAuth {
+		if target, err = o.createOAuthProxy(routeName); err != nil {
+			return err
+		}
+		port = oauthProxyPortName
+	}
+
+	route := &routeapi.Route{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   routeName,
+			Labels: clusterLabels(webuiType, o.Name),
+		},
+		Spec: routeapi.RouteSpec{
+			Host: o.Hostname,
+			To:   kapi.ObjectReference{Kind: "Service", Name: target},
+			Port: &routeapi.RoutePort{TargetPort: intstr.FromString(port)},
+			TLS: &routeapi.TLSConfig{
+				Termination:                   routeapi.TLSTerminationEdge,
+				InsecureEdgeTerminationPolicy: routeapi.InsecureEdgeTerminationPolicyRedirect,
+			},
+		},
+	}
+	created, err := o.Client.Routes(o.Project).Create(route)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "web UI of cluster %q exposed at https://%s\n", o.Name, created.Spec.Host)
+	return nil
+}
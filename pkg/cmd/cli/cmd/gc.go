@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	kapierrors "k8s.io/kubernetes/pkg/api/errors"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployreaper "github.com/openshift/origin/pkg/deploy/reaper"
+)
+
+const (
+	gcLong = `
+Delete or idle clusters which are no longer needed.
+
+Clusters are found through the oshinko-cluster label. A cluster whose time to
+live has passed is deleted. With --idle-for, a cluster whose master has run no
+spark application for at least that long is idled. A report of the affected
+clusters is printed. Use --dry-run to see the report without changing anything.`
+
+	gcExample = `  # Show which clusters would be collected
+  %[1]s gc --dry-run --idle-for 2h
+
+  # Delete expired clusters and idle those unused for 2 hours
+  %[1]s gc --idle-for 2h`
+)
+
+const reapTimeout = 2 * time.Minute
+
+// deleteCluster removes the deployment configs, their deployments and pods,
+// the services, the routes, the secrets, the service accounts, the scratch
+// claims, the config maps and the isolation policy of a cluster
+func deleteCluster(oClient *client.Client, kClient *kclient.Client, namespace, clustername string) error {
+	selector := makeSelector("", clustername)
+	dcs, err := oClient.DeploymentConfigs(namespace).List(selector)
+	if err != nil {
+		return err
+	}
+	reaper := deployreaper.NewDeploymentConfigReaper(oClient, kClient)
+	for _, dc := range dcs.Items {
+		if err := reaper.Stop(namespace, dc.Name, reapTimeout, nil); err != nil {
+			return err
+		}
+	}
+
+	srvs, err := kClient.Services(namespace).List(selector)
+	if err != nil {
+		return err
+	}
+	for _, srv := range srvs.Items {
+		if err := kClient.Services(namespace).Delete(srv.Name); err != nil {
+			return err
+		}
+	}
+
+	routes, err := oClient.Routes(namespace).List(selector)
+	if err != nil {
+		return err
+	}
+	for _, route := range routes.Items {
+		if err := oClient.Routes(namespace).Delete(route.Name); err != nil {
+			return err
+		}
+	}
+
+	secrets, err := kClient.Secrets(namespace).List(selector)
+	if err != nil {
+		return err
+	}
+	for _, secret := range secrets.Items {
+		if err := kClient.Secrets(namespace).Delete(secret.Name); err != nil {
+			return err
+		}
+	}
+
+	accounts, err := kClient.ServiceAccounts(namespace).List(selector)
+	if err != nil {
+		return err
+	}
+	for _, sa := range accounts.Items {
+		if err := kClient.ServiceAccounts(namespace).Delete(sa.Name); err != nil {
+			return err
+		}
+	}
+
+	claims, err := kClient.PersistentVolumeClaims(namespace).List(selector)
+	if err != nil {
+		return err
+	}
+	for _, claim := range claims.Items {
+		if err := kClient.PersistentVolumeClaims(namespace).Delete(claim.Name); err != nil {
+			return err
+		}
+	}
+
+	configMaps, err := kClient.ConfigMaps(namespace).List(selector)
+	if err != nil {
+		return err
+	}
+	for _, cm := range configMaps.Items {
+		if err := kClient.ConfigMaps(namespace).Delete(cm.Name); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteIsolationPolicy(kClient, namespace, clustername); err != nil && !kapierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// lastActivity returns the time the last spark application of a cluster
+// finished, or the time the master started if it never ran one
+func lastActivity(kClient *kclient.Client, namespace, clustername string, state *sparkMasterState) time.Time {
+	var last time.Time
+	for _, app := range state.CompletedApps {
+		end := time.Unix(0, (app.StartTime+app.Duration)*int64(time.Millisecond))
+		if end.After(last) {
+			last = end
+		}
+	}
+	if last.IsZero() {
+		if pod, err := runningMasterPod(kClient, namespace, clustername); err == nil && pod.Status.StartTime != nil {
+			last = pod.Status.StartTime.Time
+		}
+	}
+	return last
+}
+
+type GcOptions struct {
+	ClusterCmdOptions
+
+	DryRun  bool
+	IdleFor time.Duration
+}
+
+// NewCmdGc implements the oshinko gc command
+func NewCmdGc(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &GcOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "gc",
+		Short:   "Delete expired clusters and idle unused ones",
+		Long:    gcLong,
+		Example: fmt.Sprintf(gcExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunGc(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.DryRun, "dry-run", false, "If true, only report the clusters which would be collected")
+	cmd.Flags().DurationVar(&options.IdleFor, "idle-for", 0, "Idle clusters which ran no application for this long, disabled if 0")
+	return cmd
+}
+
+func (o *GcOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if len(args) > 0 {
+		return fmt.Errorf("no arguments should be passed")
+	}
+	return o.completeClients(f, out)
+}
+
+func (o *GcOptions) RunGc() error {
+	names, err := clusterNames(o.Client, o.Project)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	report := []string{}
+	for _, name := range names {
+		master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, name)
+		if err != nil {
+			return err
+		}
+
+		var reason, action string
+		if expiry := clusterExpiry(master, worker); !expiry.IsZero() && now.After(expiry) {
+			reason = "expired at " + expiry.Format(time.RFC3339)
+			action = "deleted"
+		} else if o.IdleFor > 0 && master != nil && !isIdled(master) {
+			state, err := getMasterState(o.KClient, o.Project, name)
+			if err != nil || len(state.ActiveApps) > 0 {
+				continue
+			}
+			last := lastActivity(o.KClient, o.Project, name, state)
+			if last.IsZero() || now.Sub(last) < o.IdleFor {
+				continue
+			}
+			reason = fmt.Sprintf("no applications for %v", now.Sub(last)/time.Minute*time.Minute)
+			action = "idled"
+		} else {
+			continue
+		}
+
+		if o.DryRun {
+			action = "would be " + action
+		} else if action == "deleted" {
+			err = deleteCluster(o.Client, o.KClient, o.Project, name)
+		} else {
+			err = idleCluster(o.Client, o.Project, name)
+		}
+		if err != nil {
+			action = "failed: " + err.Error()
+		}
+		report = append(report, fmt.Sprintf("%s\t%s\t%s", name, reason, action))
+	}
+
+	if len(report) == 0 {
+		fmt.Fprintln(o.Out, "There are no clusters to collect.")
+		return nil
+	}
+	w := kubectl.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	fmt.Fprintln(w, "CLUSTER\tREASON\tACTION")
+	for _, line := range report {
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+)
+
+const (
+	historyLong = `
+List the configuration revisions of a cluster.
+
+Every deployment of the master or the workers creates a new revision of the
+cluster made of the master and worker deployments current at that time. The
+revision numbers can be passed to the rollback command. CHANGED BY names the
+user who made the change through oshinko, changes made otherwise, for example
+with oc edit, are shown as <unknown>.`
+
+	historyExample = `  # List the revisions of the cluster 'mycluster'
+  %[1]s history mycluster`
+)
+
+// clusterRevision is the pair of master and worker deployments which were
+// current together at some point in the life of a cluster
+type clusterRevision struct {
+	Number int
+	Master *kapi.ReplicationController
+	Worker *kapi.ReplicationController
+	// Cause is the deployment which created this revision
+	Cause *kapi.ReplicationController
+}
+
+type byCreation []*kapi.ReplicationController
+
+func (d byCreation) Len() int      { return len(d) }
+func (d byCreation) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
+func (d byCreation) Less(i, j int) bool {
+	if d[i].CreationTimestamp.Equal(d[j].CreationTimestamp) {
+		return d[i].Name < d[j].Name
+	}
+	return d[i].CreationTimestamp.Before(d[j].CreationTimestamp)
+}
+
+// clusterRevisions merges the deployments of the master and worker configs
+// into a single ordered list of cluster revisions
+func clusterRevisions(kClient *kclient.Client, namespace string, master, worker *deployapi.DeploymentConfig) ([]clusterRevision, error) {
+	events := []*kapi.ReplicationController{}
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil {
+			continue
+		}
+		rcs, err := kClient.ReplicationControllers(namespace).List(kapi.ListOptions{LabelSelector: deployutil.ConfigSelector(dc.Name)})
+		if err != nil {
+			return nil, err
+		}
+		for i := range rcs.Items {
+			events = append(events, &rcs.Items[i])
+		}
+	}
+	sort.Sort(byCreation(events))
+
+	revisions := []clusterRevision{}
+	current := clusterRevision{}
+	for _, rc := range events {
+		if master != nil && deployutil.DeploymentConfigNameFor(rc) == master.Name {
+			current.Master = rc
+		} else {
+			current.Worker = rc
+		}
+		current.Cause = rc
+		current.Number = len(revisions) + 1
+		revisions = append(revisions, current)
+	}
+	return revisions, nil
+}
+
+// deploymentSummary describes the image, replicas and author of a deployment
+func deploymentSummary(rc *kapi.ReplicationController) (string, int, string) {
+	if rc == nil {
+		return "<none>", 0, ""
+	}
+	image := "<none>"
+	if c := sparkContainer(rc.Spec.Template); c != nil {
+		image = c.Image
+	}
+	replicas, ok := deployutil.DeploymentDesiredReplicas(rc)
+	if !ok {
+		replicas = rc.Spec.Replicas
+	}
+
+	author := "<unknown>"
+	if config, err := deployutil.DecodeDeploymentConfig(rc, kapi.Codecs.UniversalDecoder()); err == nil {
+		author = changeAuthor(config, deployutil.DeploymentVersionFor(rc))
+	}
+	return image, replicas, author
+}
+
+type HistoryOptions struct {
+	ClusterCmdOptions
+}
+
+// NewCmdHistory implements the oshinko history command
+func NewCmdHistory(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &HistoryOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "history <NAME>",
+		Short:   "List the configuration revisions of a cluster",
+		Long:    historyLong,
+		Example: fmt.Sprintf(historyExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunHistory(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	return cmd
+}
+
+func (o *HistoryOptions) RunHistory() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	revisions, err := clusterRevisions(o.KClient, o.Project, master, worker)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		fmt.Fprintf(o.Out, "cluster %q has no revisions\n", o.Name)
+		return nil
+	}
+
+	w := kubectl.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	fmt.Fprintln(w, "REVISION\tCREATED\tMASTER IMAGE\tWORKER IMAGE\tWORKERS\tCHANGED BY")
+	for _, r := range revisions {
+		masterImage, _, _ := deploymentSummary(r.Master)
+		workerImage, replicas, _ := deploymentSummary(r.Worker)
+		_, _, author := deploymentSummary(r.Cause)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\n", r.Number, r.Cause.CreationTimestamp.Format("2006-01-02 15:04:05"), masterImage, workerImage, replicas, author)
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/util/intstr"
+
+	"github.com/openshift/origin/pkg/client"
+	ocutil "github.com/openshift/origin/pkg/cmd/util"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	routeapi "github.com/openshift/origin/pkg/route/api"
+)
+
+const (
+	historyServerLong = `
+Manage spark history servers.`
+
+	historyServerCreateLong = `
+Deploy a spark history server reading the event logs of a cluster.
+
+The argument is either a cluster which has an event log claim attached with
+the storage command, or the name of a claim holding event logs. The history
+server runs the spark image of the cluster, or --image, and mounts the claim
+read only. It carries the oshinko-cluster label of the cluster with the
+oshinko-type history, so it is shown and deleted with the cluster. A history
+server created from a claim is labelled with the claim name and outlives the
+clusters writing to it.
+
+A service named <NAME>-history is created, and a route too with --route.`
+
+	historyServerCreateExample = `  # Deploy a history server for the cluster 'mycluster' and expose it
+  %[1]s history-server create mycluster --route
+
+  # Deploy a history server for the event logs kept on the claim 'spark-events'
+  %[1]s history-server create spark-events --image radanalyticsio/openshift-spark`
+)
+
+const (
+	historyType     = "history"
+	historyPortName = "spark-history"
+	historyPort     = 18080
+
+	historyServerClass = "org.apache.spark.deploy.history.HistoryServer"
+	sparkClassPath     = "/opt/spark/bin/spark-class"
+	historyOptsEnv     = "SPARK_HISTORY_OPTS"
+)
+
+func historyServerName(name string) string {
+	return name + "-history"
+}
+
+// eventLogClaim returns the claim holding the event logs of a cluster
+func eventLogClaim(dcs ...*deployapi.DeploymentConfig) string {
+	for _, dc := range dcs {
+		if dc == nil {
+			continue
+		}
+		for _, v := range dc.Spec.Template.Spec.Volumes {
+			if v.Name == eventLogVolumeName && v.PersistentVolumeClaim != nil {
+				return v.PersistentVolumeClaim.ClaimName
+			}
+		}
+	}
+	return ""
+}
+
+// newHistoryServerDeploymentConfig builds a history server reading the event
+// logs kept on a claim
+func newHistoryServerDeploymentConfig(name, clustername, image, claim string) *deployapi.DeploymentConfig {
+	selector := clusterLabels(historyType, clustername)
+	selector[deployapi.DeploymentConfigLabel] = name
+
+	template := &kapi.PodTemplateSpec{
+		ObjectMeta: kapi.ObjectMeta{Labels: selector},
+		Spec: kapi.PodSpec{
+			Containers: []kapi.Container{
+				{
+					Name:    name,
+					Image:   image,
+					Command: []string{sparkClassPath, historyServerClass},
+					Env: []kapi.EnvVar{
+						{Name: historyOptsEnv, Value: "-Dspark.history.fs.logDirectory=file://" + eventLogMountPath},
+					},
+					Ports: []kapi.ContainerPort{
+						{Name: historyPortName, ContainerPort: historyPort, Protocol: kapi.ProtocolTCP},
+					},
+				},
+			},
+		},
+	}
+	setVolume(template, kapi.Volume{
+		Name: eventLogVolumeName,
+		VolumeSource: kapi.VolumeSource{
+			PersistentVolumeClaim: &kapi.PersistentVolumeClaimVolumeSource{ClaimName: claim, ReadOnly: true},
+		},
+	}, eventLogMountPath)
+
+	return &deployapi.DeploymentConfig{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   name,
+			Labels: clusterLabels(historyType, clustername),
+		},
+		Spec: deployapi.DeploymentConfigSpec{
+			Strategy: deployapi.DeploymentStrategy{Type: deployapi.DeploymentStrategyTypeRecreate},
+			Triggers: []deployapi.DeploymentTriggerPolicy{
+				{Type: deployapi.DeploymentTriggerOnConfigChange},
+			},
+			Replicas: 1,
+			Selector: selector,
+			Template: template,
+		},
+	}
+}
+
+// historyServerRoute returns the host of the route of the history server of a cluster
+func historyServerRoute(oClient *client.Client, namespace, clustername string) string {
+	routes, err := oClient.Routes(namespace).List(makeSelector(historyType, clustername))
+	if err != nil || len(routes.Items) == 0 {
+		return ""
+	}
+	return routes.Items[0].Spec.Host
+}
+
+// describeHistoryServer shows the history server deployed for a cluster
+func describeHistoryServer(w io.Writer, oClient *client.Client, namespace, clustername string) {
+	dcs, err := oClient.DeploymentConfigs(namespace).List(makeSelector(historyType, clustername))
+	if err != nil || len(dcs.Items) == 0 {
+		fmt.Fprintln(w, "History Server:\t<none>")
+		return
+	}
+	fmt.Fprintf(w, "History Server:\t%s\n", dcs.Items[0].Name)
+	if host := historyServerRoute(oClient, namespace, clustername); host != "" {
+		fmt.Fprintf(w, "  Route:\thttp://%s\n", host)
+	}
+}
+
+// NewCmdHistoryServer implements the oshinko history-server command
+func NewCmdHistoryServer(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history-server",
+		Short: "Manage spark history servers",
+		Long:  historyServerLong,
+		Run:   ocutil.DefaultSubCommandRun(out),
+	}
+	cmd.AddCommand(NewCmdHistoryServerCreate(fullName, f, out))
+	return cmd
+}
+
+type HistoryServerOptions struct {
+	ClusterCmdOptions
+
+	Image    string
+	Route    bool
+	Hostname string
+}
+
+// NewCmdHistoryServerCreate implements the oshinko history-server create command
+func NewCmdHistoryServerCreate(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &HistoryServerOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "create <CLUSTER|CLAIM>",
+		Short:   "Deploy a history server for a cluster or an event log claim",
+		Long:    historyServerCreateLong,
+		Example: fmt.Sprintf(historyServerCreateExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunHistoryServerCreate(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Image, "image", "", "The spark image to run, defaults to the image of the cluster")
+	cmd.Flags().BoolVar(&options.Route, "route", false, "If true, expose the history server with a route")
+	cmd.Flags().StringVar(&options.Hostname, "hostname", "", "The host name of the route, generated if empty")
+	return cmd
+}
+
+func (o *HistoryServerOptions) RunHistoryServerCreate() error {
+	clustername := o.Name
+	image := o.Image
+	var claim string
+
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err == nil {
+		claim = eventLogClaim(master, worker)
+		if claim == "" {
+			return fmt.Errorf("cluster %q keeps no event logs, attach a claim with the storage command first", o.Name)
+		}
+		if image == "" {
+			for _, dc := range []*deployapi.DeploymentConfig{worker, master} {
+				if dc == nil {
+					continue
+				}
+				if c := sparkContainer(dc.Spec.Template); c != nil {
+					image = c.Image
+					break
+				}
+			}
+		}
+	} else {
+		if _, err := o.KClient.PersistentVolumeClaims(o.Project).Get(o.Name); err != nil {
+			return fmt.Errorf("%q is neither a cluster nor a persistent volume claim", o.Name)
+		}
+		claim = o.Name
+	}
+	if image == "" {
+		return fmt.Errorf("--image must be specified for a history server created from a claim")
+	}
+
+	name := historyServerName(o.Name)
+	dc := newHistoryServerDeploymentConfig(name, clustername, image, claim)
+	if err := checkCapacity(o.KClient, o.Project, []podShape{{dc.Spec.Template, dc.Spec.Replicas}}); err != nil {
+		return err
+	}
+	if _, err := o.Client.DeploymentConfigs(o.Project).Create(dc); err != nil {
+		return err
+	}
+
+	srv := &kapi.Service{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   name,
+			Labels: clusterLabels(historyType, clustername),
+		},
+		Spec: kapi.ServiceSpec{
+			Selector: clusterLabels(historyType, clustername),
+			Ports: []kapi.ServicePort{
+				{
+					Name:       historyPortName,
+					Protocol:   kapi.ProtocolTCP,
+					Port:       historyPort,
+					TargetPort: intstr.FromInt(historyPort),
+				},
+			},
+		},
+	}
+	if _, err := o.KClient.Services(o.Project).Create(srv); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "history server %s created for %q\n", name, o.Name)
+
+	if o.Route {
+		route := &routeapi.Route{
+			ObjectMeta: kapi.ObjectMeta{
+				Name:   name,
+				Labels: clusterLabels(historyType, clustername),
+			},
+			Spec: routeapi.RouteSpec{
+				Host: o.Hostname,
+				To:   kapi.ObjectReference{Kind: "Service", Name: srv.Name},
+				Port: &routeapi.RoutePort{TargetPort: intstr.FromString(historyPortName)},
+			},
+		}
+		created, err := o.Client.Routes(o.Project).Create(route)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(o.Out, "history server exposed at http://%s\n", created.Spec.Host)
+	}
+	return nil
+}
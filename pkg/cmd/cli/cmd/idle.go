@@ -0,0 +1,225 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	idleLong = `
+Scale a cluster down to zero to release its quota.
+
+The current replica counts of the master and the workers are recorded in
+annotations on their deployment configs before both are scaled to zero.
+Idled clusters are still listed. Use unidle to bring the cluster back.`
+
+	idleExample = `  # Idle the cluster 'mycluster' for the night
+  %[1]s idle mycluster`
+
+	unidleLong = `
+Restore an idled cluster.
+
+The master and the workers are scaled back to the replica counts recorded
+when the cluster was idled. The command waits until the master and then all
+workers are ready.`
+
+	unidleExample = `  # Bring back the cluster 'mycluster'
+  %[1]s unidle mycluster`
+)
+
+// idledReplicasAnnotation holds the replica count of an idled deployment config
+const idledReplicasAnnotation = "oshinko-idled-replicas"
+
+// idledAtAnnotation holds the time at which a deployment config was idled
+const idledAtAnnotation = "oshinko-idled-at"
+
+const idledStatus = "Idled"
+
+// isIdled reports whether a deployment config was idled by oshinko
+func isIdled(dc *deployapi.DeploymentConfig) bool {
+	_, ok := dc.Annotations[idledReplicasAnnotation]
+	return ok
+}
+
+// activeReplicas returns the replica count of a deployment config when it
+// runs, which for an idled config is the count recorded when it was idled
+func activeReplicas(dc *deployapi.DeploymentConfig) (int, error) {
+	if !isIdled(dc) {
+		return dc.Spec.Replicas, nil
+	}
+	replicas, err := strconv.Atoi(dc.Annotations[idledReplicasAnnotation])
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s annotation on %s: %v", idledReplicasAnnotation, dc.Name, err)
+	}
+	return replicas, nil
+}
+
+// scaleDeploymentConfig scales the active deployment of a config without
+// starting a new deployment
+func scaleDeploymentConfig(oClient *client.Client, namespace, name string, replicas int) error {
+	scale, err := oClient.DeploymentConfigs(namespace).GetScale(name)
+	if err != nil {
+		return err
+	}
+	scale.Spec.Replicas = replicas
+	_, err = oClient.DeploymentConfigs(namespace).UpdateScale(scale)
+	return err
+}
+
+// idleCluster records the replica counts of a cluster and scales it to zero,
+// workers first
+func idleCluster(oClient *client.Client, namespace, clustername string) error {
+	master, worker, err := clusterDeploymentConfigs(oClient, namespace, clustername)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, dc := range []*deployapi.DeploymentConfig{worker, master} {
+		if dc == nil || isIdled(dc) {
+			continue
+		}
+		if dc.Annotations == nil {
+			dc.Annotations = make(map[string]string)
+		}
+		dc.Annotations[idledReplicasAnnotation] = strconv.Itoa(dc.Spec.Replicas)
+		dc.Annotations[idledAtAnnotation] = now
+		if _, err := oClient.DeploymentConfigs(namespace).Update(dc); err != nil {
+			return err
+		}
+		if err := scaleDeploymentConfig(oClient, namespace, dc.Name, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unidleCluster restores the recorded replica counts of an idled cluster,
+// master first, and waits for the pods to be ready
+func unidleCluster(oClient *client.Client, kClient *kclient.Client, namespace, clustername string, timeout time.Duration) error {
+	master, worker, err := clusterDeploymentConfigs(oClient, namespace, clustername)
+	if err != nil {
+		return err
+	}
+	if (master == nil || !isIdled(master)) && (worker == nil || !isIdled(worker)) {
+		return fmt.Errorf("cluster %q is not idled", clustername)
+	}
+	restored := map[string]int{}
+	shapes := []podShape{}
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil || !isIdled(dc) {
+			continue
+		}
+		replicas, err := activeReplicas(dc)
+		if err != nil {
+			return err
+		}
+		restored[dc.Name] = replicas
+		shapes = append(shapes, podShape{dc.Spec.Template, replicas - dc.Spec.Replicas})
+	}
+	if err := checkCapacity(kClient, namespace, shapes); err != nil {
+		return err
+	}
+
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil || !isIdled(dc) {
+			continue
+		}
+		replicas := restored[dc.Name]
+		if err := scaleDeploymentConfig(oClient, namespace, dc.Name, replicas); err != nil {
+			return err
+		}
+		if err := waitForClusterPods(kClient, namespace, clustername, dc.Labels[typeLabel], "", replicas, timeout); err != nil {
+			return err
+		}
+
+		dc, err = oClient.DeploymentConfigs(namespace).Get(dc.Name)
+		if err != nil {
+			return err
+		}
+		delete(dc.Annotations, idledReplicasAnnotation)
+		delete(dc.Annotations, idledAtAnnotation)
+		if _, err := oClient.DeploymentConfigs(namespace).Update(dc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type IdleOptions struct {
+	ClusterCmdOptions
+
+	Timeout time.Duration
+}
+
+// NewCmdIdle implements the oshinko idle command
+func NewCmdIdle(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &IdleOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "idle <NAME>",
+		Short:   "Scale a cluster to zero, remembering its size",
+		Long:    idleLong,
+		Example: fmt.Sprintf(idleExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunIdle(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	return cmd
+}
+
+// NewCmdUnidle implements the oshinko unidle command
+func NewCmdUnidle(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &IdleOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "unidle <NAME>",
+		Short:   "Restore an idled cluster",
+		Long:    unidleLong,
+		Example: fmt.Sprintf(unidleExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunUnidle(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().DurationVar(&options.Timeout, "timeout", 5*time.Minute, "How long to wait for the cluster to be ready")
+	return cmd
+}
+
+func (o *IdleOptions) RunIdle() error {
+	if err := idleCluster(o.Client, o.Project, o.Name); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "cluster %q idled\n", o.Name)
+	return nil
+}
+
+func (o *IdleOptions) RunUnidle() error {
+	if err := unidleCluster(o.Client, o.KClient, o.Project, o.Name, o.Timeout); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "cluster %q is ready\n", o.Name)
+	return nil
+}
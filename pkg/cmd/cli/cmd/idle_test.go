@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+func TestActiveReplicas(t *testing.T) {
+	tests := []struct {
+		name        string
+		replicas    int
+		annotations map[string]string
+		expected    int
+		expectErr   bool
+	}{
+		{name: "running", replicas: 3, expected: 3},
+		{name: "idled", replicas: 0, annotations: map[string]string{idledReplicasAnnotation: "4"}, expected: 4},
+		{name: "invalid annotation", replicas: 0, annotations: map[string]string{idledReplicasAnnotation: "many"}, expectErr: true},
+	}
+
+	for _, test := range tests {
+		dc := &deployapi.DeploymentConfig{
+			ObjectMeta: kapi.ObjectMeta{Name: "spark-w", Annotations: test.annotations},
+			Spec:       deployapi.DeploymentConfigSpec{Replicas: test.replicas},
+		}
+		got, err := activeReplicas(dc)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %d", test.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.name, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kapierrors "k8s.io/kubernetes/pkg/api/errors"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+)
+
+const (
+	isolateLong = `
+Restrict the pods allowed to reach the master and the workers of a cluster.
+
+A NetworkPolicy named <NAME>-isolation lets only the pods of the cluster, such
+as its history server and OAuth proxy, the pods of the applications linked to
+the cluster and the pods matching one of the --allow-label selectors, such as
+drivers, connect to the master and the workers. The history server and the
+OAuth proxy stay reachable by the router.
+
+The policy is enforced by network plugins supporting NetworkPolicy. Servers
+providing the networking.k8s.io/v1 API enforce it as soon as it exists. With
+only the beta API, it is enforced in projects whose ingress isolation is
+DefaultDeny:
+
+  oc annotate namespace <PROJECT> \
+    net.beta.kubernetes.io/network-policy='{"ingress":{"isolation":"DefaultDeny"}}'
+
+isolate warns when the beta API is used and the project lacks this annotation.
+
+Running isolate again replaces the allowed labels, --remove lifts the
+isolation.`
+
+	isolateExample = `  # Only let the pods labelled app=etl reach the cluster 'mycluster'
+  %[1]s isolate mycluster --allow-label app=etl
+
+  # Lift the isolation of the cluster 'mycluster'
+  %[1]s isolate mycluster --remove`
+)
+
+const (
+	isolationType = "isolation"
+
+	// networkPolicyAnnotation holds the ingress isolation of a namespace,
+	// policies are ignored unless it is DefaultDeny
+	networkPolicyAnnotation = "net.beta.kubernetes.io/network-policy"
+	defaultDeny             = "DefaultDeny"
+
+	// networkingV1 enforces any network policy, the annotation is ignored
+	networkingV1 = "networking.k8s.io/v1"
+)
+
+func isolationPolicyName(clustername string) string {
+	return clustername + "-isolation"
+}
+
+// The vendored client predates NetworkPolicy, the types below and labelSelector
+// are the parts of extensions/v1beta1 used by oshinko
+
+type networkPolicyPeer struct {
+	PodSelector *labelSelector `json:"podSelector,omitempty"`
+}
+
+type networkPolicyIngressRule struct {
+	From []networkPolicyPeer `json:"from,omitempty"`
+}
+
+type networkPolicySpec struct {
+	PodSelector labelSelector              `json:"podSelector"`
+	Ingress     []networkPolicyIngressRule `json:"ingress,omitempty"`
+}
+
+type networkPolicyMeta struct {
+	Name            string            `json:"name"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+}
+
+type networkPolicy struct {
+	Kind       string            `json:"kind"`
+	APIVersion string            `json:"apiVersion"`
+	Metadata   networkPolicyMeta `json:"metadata"`
+	Spec       networkPolicySpec `json:"spec"`
+}
+
+// newIsolationPolicy lets the pods of a cluster, of its linked applications
+// and the pods matching one of the allowed selectors reach its master and workers
+func newIsolationPolicy(clustername string, allowed []map[string]string) *networkPolicy {
+	from := []networkPolicyPeer{
+		{PodSelector: &labelSelector{MatchLabels: map[string]string{clusterLabel: clustername}}},
+		{PodSelector: &labelSelector{MatchLabels: linkedPodSelector(clustername)}},
+	}
+	for _, selector := range allowed {
+		from = append(from, networkPolicyPeer{PodSelector: &labelSelector{MatchLabels: selector}})
+	}
+	return &networkPolicy{
+		Kind:       "NetworkPolicy",
+		APIVersion: "extensions/v1beta1",
+		Metadata: networkPolicyMeta{
+			Name:   isolationPolicyName(clustername),
+			Labels: clusterLabels(isolationType, clustername),
+		},
+		Spec: networkPolicySpec{
+			PodSelector: labelSelector{
+				MatchLabels: map[string]string{clusterLabel: clustername},
+				MatchExpressions: []labelSelectorRequirement{
+					{Key: typeLabel, Operator: "In", Values: []string{masterType, workerType}},
+				},
+			},
+			Ingress: []networkPolicyIngressRule{{From: from}},
+		},
+	}
+}
+
+// getIsolationPolicy returns the isolation policy of a cluster, or nil
+func getIsolationPolicy(kClient *kclient.Client, namespace, clustername string) (*networkPolicy, error) {
+	body, err := kClient.ExtensionsClient.Get().
+		Namespace(namespace).
+		Resource("networkpolicies").
+		Name(isolationPolicyName(clustername)).
+		Do().
+		Raw()
+	if kapierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	policy := &networkPolicy{}
+	if err := json.Unmarshal(body, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// enforcesPolicies tells whether network policies take effect in a
+// namespace: always with the v1 API, only when the ingress isolation of the
+// namespace is DefaultDeny with the beta API
+func enforcesPolicies(kClient *kclient.Client, namespace string) (bool, error) {
+	v1, err := servesResource(kClient, networkingV1, "networkpolicies")
+	if err != nil || v1 {
+		return v1, err
+	}
+	ns, err := kClient.Namespaces().Get(namespace)
+	if err != nil {
+		return false, err
+	}
+	value, ok := ns.Annotations[networkPolicyAnnotation]
+	if !ok {
+		return false, nil
+	}
+	isolation := struct {
+		Ingress struct {
+			Isolation string `json:"isolation"`
+		} `json:"ingress"`
+	}{}
+	if err := json.Unmarshal([]byte(value), &isolation); err != nil {
+		return false, nil
+	}
+	return isolation.Ingress.Isolation == defaultDeny, nil
+}
+
+func deleteIsolationPolicy(kClient *kclient.Client, namespace, clustername string) error {
+	return kClient.ExtensionsClient.Delete().
+		Namespace(namespace).
+		Resource("networkpolicies").
+		Name(isolationPolicyName(clustername)).
+		Do().
+		Error()
+}
+
+// allowedSelectors returns the selectors of the pods allowed into a cluster
+// besides its own pods
+func (p *networkPolicy) allowedSelectors() []string {
+	allowed := []string{}
+	for _, rule := range p.Spec.Ingress {
+		for _, peer := range rule.From {
+			if peer.PodSelector == nil || len(peer.PodSelector.MatchLabels) == 0 {
+				continue
+			}
+			if len(peer.PodSelector.MatchLabels) == 1 {
+				_, own := peer.PodSelector.MatchLabels[clusterLabel]
+				_, linked := peer.PodSelector.MatchLabels[linkedLabel]
+				if own || linked {
+					continue
+				}
+			}
+			allowed = append(allowed, formatNodeSelector(peer.PodSelector.MatchLabels))
+		}
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
+// describeIsolation shows whether the master and the workers of a cluster are isolated
+func describeIsolation(w io.Writer, kClient *kclient.Client, namespace, clustername string) {
+	policy, err := getIsolationPolicy(kClient, namespace, clustername)
+	switch {
+	case err != nil:
+		fmt.Fprintln(w, "Isolated:\tunknown")
+	case policy == nil:
+		fmt.Fprintln(w, "Isolated:\tno")
+	default:
+		allowed := "cluster pods only"
+		if selectors := policy.allowedSelectors(); len(selectors) > 0 {
+			allowed = "cluster pods and " + strings.Join(selectors, ", ")
+		}
+		enforced, err := enforcesPolicies(kClient, namespace)
+		switch {
+		case err != nil:
+			fmt.Fprintf(w, "Isolated:\tyes, %s (enforcement unknown)\n", allowed)
+		case !enforced:
+			fmt.Fprintf(w, "Isolated:\tno, the project is not %s (policy allows %s)\n", defaultDeny, allowed)
+		default:
+			fmt.Fprintf(w, "Isolated:\tyes, %s\n", allowed)
+		}
+	}
+}
+
+type IsolateOptions struct {
+	ClusterCmdOptions
+
+	AllowLabels []string
+	Remove      bool
+
+	allowed []map[string]string
+}
+
+// NewCmdIsolate implements the oshinko isolate command
+func NewCmdIsolate(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &IsolateOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "isolate <NAME>",
+		Short:   "Restrict the pods allowed to reach a cluster",
+		Long:    isolateLong,
+		Example: fmt.Sprintf(isolateExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunIsolate(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&options.AllowLabels, "allow-label", []string{}, "Let the pods with the label key=value reach the cluster, may be repeated")
+	cmd.Flags().BoolVar(&options.Remove, "remove", false, "If true, lift the isolation of the cluster")
+	return cmd
+}
+
+func (o *IsolateOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if err := o.ClusterCmdOptions.Complete(f, args, out); err != nil {
+		return err
+	}
+	if o.Remove && len(o.AllowLabels) > 0 {
+		return fmt.Errorf("--allow-label cannot be used with --remove")
+	}
+	for _, label := range o.AllowLabels {
+		selector, err := parseNodeSelector(label)
+		if err != nil {
+			return fmt.Errorf("invalid label %q, must be key=value", label)
+		}
+		o.allowed = append(o.allowed, selector)
+	}
+	return nil
+}
+
+func (o *IsolateOptions) RunIsolate() error {
+	if _, _, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name); err != nil {
+		return err
+	}
+	existing, err := getIsolationPolicy(o.KClient, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+
+	if o.Remove {
+		if existing == nil {
+			return fmt.Errorf("cluster %q is not isolated", o.Name)
+		}
+		if err := deleteIsolationPolicy(o.KClient, o.Project, o.Name); err != nil {
+			return err
+		}
+		fmt.Fprintf(o.Out, "isolation of cluster %q lifted\n", o.Name)
+		return nil
+	}
+
+	// the existing policy is replaced in place, the cluster is never left
+	// without one
+	policy := newIsolationPolicy(o.Name, o.allowed)
+	request := o.KClient.ExtensionsClient.Post()
+	if existing != nil {
+		policy.Metadata.ResourceVersion = existing.Metadata.ResourceVersion
+		request = o.KClient.ExtensionsClient.Put().Name(policy.Metadata.Name)
+	}
+	body, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	err = request.
+		Namespace(o.Project).
+		Resource("networkpolicies").
+		Body(body).
+		Do().
+		Error()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "cluster %q isolated with the network policy %s\n", o.Name, isolationPolicyName(o.Name))
+
+	enforced, err := enforcesPolicies(o.KClient, o.Project)
+	if err != nil {
+		return err
+	}
+	if !enforced {
+		fmt.Fprintf(o.Out, "warning: the ingress isolation of project %q is not %s, the policy has no effect until it is annotated with %s\n",
+			o.Project, defaultDeny, networkPolicyAnnotation)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewIsolationPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		allowed  []map[string]string
+		from     []networkPolicyPeer
+		selector []string
+	}{
+		{
+			name: "cluster pods only",
+			from: []networkPolicyPeer{
+				{PodSelector: &labelSelector{MatchLabels: map[string]string{clusterLabel: "spark"}}},
+				{PodSelector: &labelSelector{MatchLabels: map[string]string{linkedLabel: "spark"}}},
+			},
+			selector: []string{},
+		},
+		{
+			name:    "allowed labels",
+			allowed: []map[string]string{{"team": "data", "role": "driver"}, {"app": "etl"}},
+			from: []networkPolicyPeer{
+				{PodSelector: &labelSelector{MatchLabels: map[string]string{clusterLabel: "spark"}}},
+				{PodSelector: &labelSelector{MatchLabels: map[string]string{linkedLabel: "spark"}}},
+				{PodSelector: &labelSelector{MatchLabels: map[string]string{"team": "data", "role": "driver"}}},
+				{PodSelector: &labelSelector{MatchLabels: map[string]string{"app": "etl"}}},
+			},
+			selector: []string{"app=etl", "role=driver,team=data"},
+		},
+	}
+
+	podSelector := labelSelector{
+		MatchLabels: map[string]string{clusterLabel: "spark"},
+		MatchExpressions: []labelSelectorRequirement{
+			{Key: typeLabel, Operator: "In", Values: []string{masterType, workerType}},
+		},
+	}
+	for _, test := range tests {
+		policy := newIsolationPolicy("spark", test.allowed)
+		if policy.Metadata.Name != "spark-isolation" {
+			t.Errorf("%s: unexpected name %q", test.name, policy.Metadata.Name)
+		}
+		if !reflect.DeepEqual(policy.Spec.PodSelector, podSelector) {
+			t.Errorf("%s: expected the pod selector %#v, got %#v", test.name, podSelector, policy.Spec.PodSelector)
+		}
+		if len(policy.Spec.Ingress) != 1 || !reflect.DeepEqual(policy.Spec.Ingress[0].From, test.from) {
+			t.Errorf("%s: expected an ingress rule from %#v, got %#v", test.name, test.from, policy.Spec.Ingress)
+		}
+		if selectors := policy.allowedSelectors(); !reflect.DeepEqual(selectors, test.selector) {
+			t.Errorf("%s: expected the allowed selectors %v, got %v", test.name, test.selector, selectors)
+		}
+	}
+}
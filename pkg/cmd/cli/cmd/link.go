@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/util/sets"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	linkLong = `
+Point an application deployment config at a cluster.
+
+SPARK_MASTER_URL and OSHINKO_CLUSTER_NAME are set in the application
+container, and the spark options the cluster sets in SPARK_SUBMIT_OPTS are
+copied into it, except the event log settings which need the event log
+volume. An application linked to a secured cluster reads the shared secret of
+the cluster. The pods of the application are labelled so an isolated cluster
+lets them in.
+
+The link is recorded on the application and on the master deployment config,
+and linking again refreshes the settings. The values the application had for
+the options link changes are recorded too, unlink puts them back.`
+
+	linkExample = `  # Let the application 'myapp' use the cluster 'mycluster'
+  %[1]s link mycluster dc/myapp`
+
+	unlinkLong = `
+Remove the settings added by link from an application deployment config. The
+spark options link changed get back the values they had before.`
+
+	unlinkExample = `  # Stop the application 'myapp' from using the cluster 'mycluster'
+  %[1]s unlink mycluster dc/myapp`
+)
+
+const (
+	sparkMasterURLEnv  = "SPARK_MASTER_URL"
+	clusterNameEnv     = "OSHINKO_CLUSTER_NAME"
+	eventLogOptionsKey = "spark.eventLog."
+
+	// linkedClusterAnnotation names the cluster an application is linked to
+	linkedClusterAnnotation = "oshinko-linked-cluster"
+
+	// linkedOptionsAnnotation holds the spark options link set on an
+	// application with the values they had before
+	linkedOptionsAnnotation = "oshinko-linked-options"
+
+	// linkedAppsAnnotation lists the applications linked to a cluster
+	linkedAppsAnnotation = "oshinko-linked-apps"
+
+	// linkedLabel marks the pods of the applications linked to a cluster
+	linkedLabel = "oshinko-linked"
+)
+
+// linkedApps returns the applications linked to a cluster
+func linkedApps(master *deployapi.DeploymentConfig) sets.String {
+	apps := sets.NewString()
+	if master == nil {
+		return apps
+	}
+	for _, name := range strings.Split(master.Annotations[linkedAppsAnnotation], ",") {
+		if name != "" {
+			apps.Insert(name)
+		}
+	}
+	return apps
+}
+
+func setLinkedApps(master *deployapi.DeploymentConfig, apps sets.String) {
+	if apps.Len() == 0 {
+		delete(master.Annotations, linkedAppsAnnotation)
+		return
+	}
+	if master.Annotations == nil {
+		master.Annotations = make(map[string]string)
+	}
+	master.Annotations[linkedAppsAnnotation] = strings.Join(apps.List(), ",")
+}
+
+// describeLinkedApps lists the applications linked to a cluster
+func describeLinkedApps(w io.Writer, master *deployapi.DeploymentConfig) {
+	apps := linkedApps(master)
+	if apps.Len() == 0 {
+		fmt.Fprintln(w, "Linked Apps:\t<none>")
+		return
+	}
+	fmt.Fprintf(w, "Linked Apps:\t%s\n", strings.Join(apps.List(), ", "))
+}
+
+// linkedOptions returns the spark options link changed on an application
+// with their previous values, nil for the options the application did not
+// have. Older links only recorded the names of the options.
+func linkedOptions(app *deployapi.DeploymentConfig) map[string]*string {
+	options := map[string]*string{}
+	value := app.Annotations[linkedOptionsAnnotation]
+	if value == "" {
+		return options
+	}
+	if err := json.Unmarshal([]byte(value), &options); err == nil {
+		return options
+	}
+	options = map[string]*string{}
+	for _, key := range strings.Split(value, ",") {
+		if key != "" {
+			options[key] = nil
+		}
+	}
+	return options
+}
+
+// unlinkTemplate removes the settings of link from the pod template of an
+// application and restores the spark options link changed
+func unlinkTemplate(dc *deployapi.DeploymentConfig) {
+	c := sparkContainer(dc.Spec.Template)
+	removeEnv(c, sparkMasterURLEnv)
+	removeEnv(c, clusterNameEnv)
+	removeEnv(c, authSecretEnv)
+	options := linkedOptions(dc)
+	for _, key := range sets.StringKeySet(options).List() {
+		if previous := options[key]; previous != nil {
+			setJavaOption(c, submitOptsEnv, key, *previous)
+		} else {
+			removeJavaArg(c, submitOptsEnv, "-D"+key+"=")
+		}
+	}
+	delete(dc.Spec.Template.Labels, linkedLabel)
+	delete(dc.Annotations, linkedOptionsAnnotation)
+	delete(dc.Annotations, linkedClusterAnnotation)
+}
+
+// applyLink sets the master url, the cluster name and the spark options of
+// the master in the pod template of an application, and the secret of a
+// secured cluster. The options changed are recorded with their values before.
+func applyLink(app, master *deployapi.DeploymentConfig, clustername, masterURL string, secured bool) {
+	unlinkTemplate(app)
+	c := sparkContainer(app.Spec.Template)
+	before := javaOptions(c, submitOptsEnv)
+	setEnv(c, sparkMasterURLEnv, masterURL)
+	setEnv(c, clusterNameEnv, clustername)
+	if mc := sparkContainer(master.Spec.Template); mc != nil {
+		// the security settings are added by secureContainer with the secret
+		properties := javaOptions(mc, submitOptsEnv)
+		for _, key := range sets.StringKeySet(properties).List() {
+			if value := properties[key]; !strings.HasPrefix(key, eventLogOptionsKey) && !strings.Contains(value, "$(") {
+				setJavaOption(c, submitOptsEnv, key, value)
+			}
+		}
+	}
+	if secured {
+		secureContainer(c, clustername, submitOptsEnv)
+	}
+
+	after := javaOptions(c, submitOptsEnv)
+	changed := map[string]*string{}
+	for _, key := range sets.StringKeySet(before).Union(sets.StringKeySet(after)).List() {
+		previous, had := before[key]
+		if value, has := after[key]; had == has && previous == value {
+			continue
+		}
+		if had {
+			changed[key] = &previous
+		} else {
+			changed[key] = nil
+		}
+	}
+	if app.Annotations == nil {
+		app.Annotations = make(map[string]string)
+	}
+	app.Annotations[linkedClusterAnnotation] = clustername
+	if len(changed) > 0 {
+		// a map of strings always marshals
+		value, _ := json.Marshal(changed)
+		app.Annotations[linkedOptionsAnnotation] = string(value)
+	}
+	if app.Spec.Template.Labels == nil {
+		app.Spec.Template.Labels = make(map[string]string)
+	}
+	app.Spec.Template.Labels[linkedLabel] = clustername
+}
+
+// linkTemplate points the pod template of an application at a cluster and
+// returns the spark url of the master. The application is not saved.
+func linkTemplate(kClient *kclient.Client, namespace, clustername string, master, app *deployapi.DeploymentConfig) (string, error) {
+	masterURL := retrieveMasterURL(kClient.Services(namespace), clustername)
+	if masterURL == "" {
+		masterURL = masterAddress(clustername)
+	}
+	secured, err := clusterSecured(kClient, namespace, clustername)
+	if err != nil {
+		return "", err
+	}
+	applyLink(app, master, clustername, masterURL, secured)
+	return masterURL, nil
+}
+
+// recordLink adds an application to the linked applications of a cluster
+func recordLink(oClient *client.Client, namespace string, master *deployapi.DeploymentConfig, app string) error {
+	apps := linkedApps(master)
+	apps.Insert(app)
+	setLinkedApps(master, apps)
+	_, err := oClient.DeploymentConfigs(namespace).Update(master)
+	return err
+}
+
+// forgetLink removes an application from the linked applications of a cluster
+func forgetLink(oClient *client.Client, namespace string, master *deployapi.DeploymentConfig, app string) error {
+	apps := linkedApps(master)
+	apps.Delete(app)
+	setLinkedApps(master, apps)
+	_, err := oClient.DeploymentConfigs(namespace).Update(master)
+	return err
+}
+
+type LinkOptions struct {
+	ClusterCmdOptions
+
+	App string
+}
+
+// NewCmdLink implements the oshinko link command
+func NewCmdLink(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &LinkOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "link <NAME> dc/<APP>",
+		Short:   "Point an application at a cluster",
+		Long:    linkLong,
+		Example: fmt.Sprintf(linkExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunLink(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	return cmd
+}
+
+// NewCmdUnlink implements the oshinko unlink command
+func NewCmdUnlink(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &LinkOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "unlink <NAME> dc/<APP>",
+		Short:   "Remove the link between an application and a cluster",
+		Long:    unlinkLong,
+		Example: fmt.Sprintf(unlinkExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunUnlink(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	return cmd
+}
+
+func (o *LinkOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("a cluster name and an application deployment config must be specified")
+	}
+	o.App = args[1]
+	if i := strings.Index(o.App, "/"); i >= 0 {
+		if kind := o.App[:i]; kind != "dc" && kind != "deploymentconfig" && kind != "deploymentconfigs" {
+			return fmt.Errorf("only deployment configs can be linked, not %q", kind)
+		}
+		o.App = o.App[i+1:]
+	}
+	if o.App == "" {
+		return fmt.Errorf("an application deployment config must be specified")
+	}
+	return o.ClusterCmdOptions.Complete(f, args[:1], out)
+}
+
+// appDeploymentConfig returns the deployment config of the application,
+// which must not be part of a cluster
+func (o *LinkOptions) appDeploymentConfig() (*deployapi.DeploymentConfig, error) {
+	app, err := o.Client.DeploymentConfigs(o.Project).Get(o.App)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := app.Labels[clusterLabel]; ok {
+		return nil, fmt.Errorf("deployment config %s is part of a cluster", app.Name)
+	}
+	if sparkContainer(app.Spec.Template) == nil {
+		return nil, fmt.Errorf("deployment config %s has no containers", app.Name)
+	}
+	return app, nil
+}
+
+func (o *LinkOptions) RunLink() error {
+	master, _, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	if master == nil {
+		return fmt.Errorf("cluster %q has no master", o.Name)
+	}
+	app, err := o.appDeploymentConfig()
+	if err != nil {
+		return err
+	}
+	if linked := app.Annotations[linkedClusterAnnotation]; linked != "" && linked != o.Name {
+		return fmt.Errorf("deployment config %s is linked to cluster %q, unlink it first", app.Name, linked)
+	}
+
+	masterURL, err := linkTemplate(o.KClient, o.Project, o.Name, master, app)
+	if err != nil {
+		return err
+	}
+	if _, err := updateAndDeploy(o.Client, o.Project, app); err != nil {
+		return err
+	}
+	if err := recordLink(o.Client, o.Project, master, app.Name); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "deployment config %s linked to cluster %q at %s\n", app.Name, o.Name, masterURL)
+	return nil
+}
+
+func (o *LinkOptions) RunUnlink() error {
+	app, err := o.appDeploymentConfig()
+	if err != nil {
+		return err
+	}
+	if linked := app.Annotations[linkedClusterAnnotation]; linked != o.Name {
+		return fmt.Errorf("deployment config %s is not linked to cluster %q", app.Name, o.Name)
+	}
+	unlinkTemplate(app)
+	if _, err := updateAndDeploy(o.Client, o.Project, app); err != nil {
+		return err
+	}
+
+	// the cluster may be gone already
+	if master, _, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name); err == nil && master != nil {
+		if err := forgetLink(o.Client, o.Project, master, app.Name); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(o.Out, "deployment config %s unlinked from cluster %q\n", app.Name, o.Name)
+	return nil
+}
+
+// linkedPodSelector selects the pods of the applications linked to a cluster
+func linkedPodSelector(clustername string) map[string]string {
+	return map[string]string{linkedLabel: clustername}
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+func testSubmitOptsConfig(name, submitOpts string) *deployapi.DeploymentConfig {
+	return &deployapi.DeploymentConfig{
+		ObjectMeta: kapi.ObjectMeta{Name: name},
+		Spec: deployapi.DeploymentConfigSpec{
+			Template: &kapi.PodTemplateSpec{
+				Spec: kapi.PodSpec{Containers: []kapi.Container{{
+					Name: name,
+					Env:  []kapi.EnvVar{{Name: submitOptsEnv, Value: submitOpts}},
+				}}},
+			},
+		},
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestLinkTemplate(t *testing.T) {
+	master := testSubmitOptsConfig("spark-m", "-Dspark.executor.memory=4g -Dspark.cores.max=8 -Dspark.eventLog.enabled=true")
+
+	tests := []struct {
+		name            string
+		submitOpts      string
+		secured         bool
+		expectedLinked  map[string]string
+		expectedOptions map[string]*string
+		expectedUnlink  map[string]string
+	}{
+		{
+			name:       "options of the application",
+			submitOpts: "-Dspark.executor.memory=2g -Dapp.mode=batch",
+			expectedLinked: map[string]string{
+				"spark.executor.memory": "4g",
+				"spark.cores.max":       "8",
+				"app.mode":              "batch",
+			},
+			expectedOptions: map[string]*string{
+				"spark.executor.memory": stringPtr("2g"),
+				"spark.cores.max":       nil,
+			},
+			expectedUnlink: map[string]string{
+				"spark.executor.memory": "2g",
+				"app.mode":              "batch",
+			},
+		},
+		{
+			name:       "secured cluster",
+			submitOpts: "-Dspark.authenticate.secret=abc -Dspark.cores.max=8",
+			secured:    true,
+			expectedLinked: map[string]string{
+				"spark.executor.memory":                   "4g",
+				"spark.cores.max":                         "8",
+				"spark.authenticate":                      "true",
+				"spark.network.crypto.enabled":            "true",
+				"spark.authenticate.enableSaslEncryption": "true",
+				"spark.network.sasl.serverAlwaysEncrypt":  "true",
+			},
+			expectedOptions: map[string]*string{
+				"spark.executor.memory":                   nil,
+				"spark.authenticate.secret":               stringPtr("abc"),
+				"spark.authenticate":                      nil,
+				"spark.network.crypto.enabled":            nil,
+				"spark.authenticate.enableSaslEncryption": nil,
+				"spark.network.sasl.serverAlwaysEncrypt":  nil,
+			},
+			expectedUnlink: map[string]string{
+				"spark.authenticate.secret": "abc",
+				"spark.cores.max":           "8",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		app := testSubmitOptsConfig("myapp", test.submitOpts)
+		applyLink(app, master, "spark", "spark://spark:7077", test.secured)
+		c := sparkContainer(app.Spec.Template)
+		if url := envValue(c, sparkMasterURLEnv); url != "spark://spark:7077" {
+			t.Errorf("%s: expected the master url to be set, got %q", test.name, url)
+		}
+		if got := javaOptions(c, submitOptsEnv); !reflect.DeepEqual(got, test.expectedLinked) {
+			t.Errorf("%s: expected linked options %v, got %v", test.name, test.expectedLinked, got)
+		}
+		if got := linkedOptions(app); !reflect.DeepEqual(got, test.expectedOptions) {
+			t.Errorf("%s: expected recorded options %v, got %v", test.name, test.expectedOptions, got)
+		}
+		if app.Spec.Template.Labels[linkedLabel] != "spark" || app.Annotations[linkedClusterAnnotation] != "spark" {
+			t.Errorf("%s: expected the link to be recorded, got labels %v and annotations %v", test.name, app.Spec.Template.Labels, app.Annotations)
+		}
+
+		unlinkTemplate(app)
+		c = sparkContainer(app.Spec.Template)
+		if got := javaOptions(c, submitOptsEnv); !reflect.DeepEqual(got, test.expectedUnlink) {
+			t.Errorf("%s: expected restored options %v, got %v", test.name, test.expectedUnlink, got)
+		}
+		for _, e := range c.Env {
+			if e.Name != submitOptsEnv {
+				t.Errorf("%s: expected %s to be removed", test.name, e.Name)
+			}
+		}
+		if len(app.Spec.Template.Labels) != 0 || len(app.Annotations) != 0 {
+			t.Errorf("%s: expected the link to be forgotten, got labels %v and annotations %v", test.name, app.Spec.Template.Labels, app.Annotations)
+		}
+	}
+}
+
+func TestLinkedOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		expected   map[string]*string
+	}{
+		{name: "none", expected: map[string]*string{}},
+		{
+			name:       "previous values",
+			annotation: `{"spark.cores.max":null,"spark.executor.memory":"2g"}`,
+			expected:   map[string]*string{"spark.cores.max": nil, "spark.executor.memory": stringPtr("2g")},
+		},
+		{
+			name:       "names only",
+			annotation: "spark.cores.max,spark.executor.memory",
+			expected:   map[string]*string{"spark.cores.max": nil, "spark.executor.memory": nil},
+		},
+	}
+
+	for _, test := range tests {
+		app := &deployapi.DeploymentConfig{ObjectMeta: kapi.ObjectMeta{Annotations: map[string]string{}}}
+		if test.annotation != "" {
+			app.Annotations[linkedOptionsAnnotation] = test.annotation
+		}
+		if got := linkedOptions(app); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kapierrors "k8s.io/kubernetes/pkg/api/errors"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/util/intstr"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	metricsLong = `
+Summarize the metrics of a cluster.
+
+The metrics endpoints of the master and the workers are read through the API
+server proxy, so neither Prometheus nor a route is needed. The number of alive
+workers, the cores and memory used by executors and the garbage collection
+time of the executors of running applications are shown. Metrics must have
+been enabled with the enable subcommand.`
+
+	metricsExample = `  # Summarize the metrics of the cluster 'mycluster'
+  %[1]s metrics mycluster`
+
+	metricsEnableLong = `
+Expose the metrics of the master and the workers of a cluster to Prometheus.
+
+Spark is configured to publish its metrics over JMX and the Prometheus JMX
+exporter agent, which the spark image must provide at --agent-jar, serves
+them on port %d. The pods and a <NAME>-metrics service carry the
+prometheus.io scrape annotations and a metrics port. The spark UI reverse
+proxy is enabled so the executors of running applications can be inspected
+through the master.`
+
+	metricsEnableExample = `  # Enable metrics on the cluster 'mycluster'
+  %[1]s metrics enable mycluster`
+)
+
+const (
+	metricsType     = "metrics"
+	metricsPortName = "metrics"
+	metricsPort     = 7777
+
+	metricsVolumeName     = "spark-metrics"
+	metricsMountPath      = "/etc/oshinko-metrics"
+	metricsPropertiesFile = "metrics.properties"
+	jmxExporterFile       = "jmx-exporter.yaml"
+	defaultAgentJar       = "/opt/metrics/jmx_prometheus_javaagent.jar"
+
+	daemonJavaOptsEnv = "SPARK_DAEMON_JAVA_OPTS"
+
+	prometheusScrapeAnnotation = "prometheus.io/scrape"
+	prometheusPortAnnotation   = "prometheus.io/port"
+)
+
+// sparkMetricsProperties publishes the spark metrics of every instance over JMX
+const sparkMetricsProperties = `*.sink.jmx.class=org.apache.spark.metrics.sink.JmxSink
+master.source.jvm.class=org.apache.spark.metrics.source.JvmSource
+worker.source.jvm.class=org.apache.spark.metrics.source.JvmSource
+`
+
+// jmxExporterConfig names the spark gauges spark_<instance>_<gauge>
+const jmxExporterConfig = `lowercaseOutputName: true
+rules:
+- pattern: 'metrics<name=(\w+)\.(\w+), type=gauges><>Value'
+  name: spark_$1_$2
+- pattern: '.*'
+`
+
+func metricsName(clustername string) string {
+	return clustername + "-metrics"
+}
+
+// parseMetrics reads the Prometheus text format and sums the samples of each
+// metric over its labels
+func parseMetrics(data []byte) map[string]float64 {
+	metrics := map[string]float64{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, rest := line, ""
+		if i := strings.IndexAny(line, "{ "); i >= 0 {
+			name, rest = line[:i], line[i:]
+		}
+		// label values may contain spaces, the value follows the closing brace
+		if strings.HasPrefix(rest, "{") {
+			if j := strings.LastIndex(rest, "}"); j >= 0 {
+				rest = rest[j+1:]
+			}
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		if v, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			metrics[name] += v
+		}
+	}
+	return metrics
+}
+
+// podMetrics reads the metrics of every running pod of a role of a cluster
+func podMetrics(kClient *kclient.Client, namespace, clustername, otype string) ([]map[string]float64, error) {
+	pods, err := kClient.Pods(namespace).List(makeSelector(otype, clustername))
+	if err != nil {
+		return nil, err
+	}
+	all := []map[string]float64{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != kapi.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		body, err := podProxyGet(kClient, namespace, pod.Name, metricsPort, "metrics")
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the metrics of pod %s, are metrics enabled? %v", pod.Name, err)
+		}
+		all = append(all, parseMetrics(body))
+	}
+	return all, nil
+}
+
+// sparkExecutorInfo is the part of the executors of an application used by oshinko
+type sparkExecutorInfo struct {
+	ID          string `json:"id"`
+	TotalGCTime int64  `json:"totalGCTime"`
+}
+
+// executorGCTime sums the garbage collection time of the executors of a
+// running application, read through the reverse proxy of the master
+func executorGCTime(kClient *kclient.Client, namespace, masterPod, appID string) (time.Duration, error) {
+	path := fmt.Sprintf("proxy/%s/api/v1/applications/%s/executors", appID, appID)
+	body, err := podProxyGet(kClient, namespace, masterPod, webPort, path)
+	if err != nil {
+		return 0, err
+	}
+	executors := []sparkExecutorInfo{}
+	if err := json.Unmarshal(body, &executors); err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, e := range executors {
+		if e.ID != "driver" {
+			total += e.TotalGCTime
+		}
+	}
+	return time.Duration(total) * time.Millisecond, nil
+}
+
+type MetricsOptions struct {
+	ClusterCmdOptions
+
+	AgentJar string
+}
+
+// NewCmdMetrics implements the oshinko metrics command
+func NewCmdMetrics(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &MetricsOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "metrics <NAME>",
+		Short:   "Summarize the metrics of a cluster",
+		Long:    metricsLong,
+		Example: fmt.Sprintf(metricsExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunMetrics(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	cmd.AddCommand(NewCmdMetricsEnable(fullName, f, out))
+	return cmd
+}
+
+// NewCmdMetricsEnable implements the oshinko metrics enable command
+func NewCmdMetricsEnable(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &MetricsOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "enable <NAME>",
+		Short:   "Expose the metrics of a cluster to Prometheus",
+		Long:    fmt.Sprintf(metricsEnableLong, metricsPort),
+		Example: fmt.Sprintf(metricsEnableExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunMetricsEnable(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.AgentJar, "agent-jar", defaultAgentJar, "The path of the Prometheus JMX exporter agent in the spark image")
+	return cmd
+}
+
+func (o *MetricsOptions) RunMetricsEnable() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc != nil && sparkContainer(dc.Spec.Template) == nil {
+			return fmt.Errorf("deployment config %s has no containers", dc.Name)
+		}
+	}
+
+	name := metricsName(o.Name)
+	cm := &kapi.ConfigMap{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   name,
+			Labels: clusterLabels(metricsType, o.Name),
+		},
+		Data: map[string]string{
+			metricsPropertiesFile: sparkMetricsProperties,
+			jmxExporterFile:       jmxExporterConfig,
+		},
+	}
+	if _, err := o.KClient.ConfigMaps(o.Project).Create(cm); kapierrors.IsAlreadyExists(err) {
+		_, err = o.KClient.ConfigMaps(o.Project).Update(cm)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	scrape := map[string]string{
+		prometheusScrapeAnnotation: "true",
+		prometheusPortAnnotation:   strconv.Itoa(metricsPort),
+	}
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil {
+			continue
+		}
+		template := dc.Spec.Template
+		setVolume(template, kapi.Volume{
+			Name: metricsVolumeName,
+			VolumeSource: kapi.VolumeSource{
+				ConfigMap: &kapi.ConfigMapVolumeSource{LocalObjectReference: kapi.LocalObjectReference{Name: name}},
+			},
+		}, metricsMountPath)
+
+		c := sparkContainer(template)
+		if !hasContainerPort(c, metricsPort) {
+			c.Ports = append(c.Ports, kapi.ContainerPort{Name: metricsPortName, ContainerPort: metricsPort, Protocol: kapi.ProtocolTCP})
+		}
+		setJavaArg(c, daemonJavaOptsEnv, "-javaagent:"+o.AgentJar, fmt.Sprintf("-javaagent:%s=%d:%s/%s", o.AgentJar, metricsPort, metricsMountPath, jmxExporterFile))
+		setJavaOption(c, daemonJavaOptsEnv, "spark.metrics.conf", metricsMountPath+"/"+metricsPropertiesFile)
+		setJavaOption(c, daemonJavaOptsEnv, "spark.ui.reverseProxy", "true")
+		setJavaOption(c, submitOptsEnv, "spark.ui.reverseProxy", "true")
+
+		if template.Annotations == nil {
+			template.Annotations = make(map[string]string)
+		}
+		for k, v := range scrape {
+			template.Annotations[k] = v
+		}
+	}
+
+	srv := &kapi.Service{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:        name,
+			Labels:      clusterLabels(metricsType, o.Name),
+			Annotations: scrape,
+		},
+		Spec: kapi.ServiceSpec{
+			// only the pods declaring the named port become endpoints
+			Selector: map[string]string{clusterLabel: o.Name},
+			Ports: []kapi.ServicePort{
+				{
+					Name:       metricsPortName,
+					Protocol:   kapi.ProtocolTCP,
+					Port:       metricsPort,
+					TargetPort: intstr.FromString(metricsPortName),
+				},
+			},
+		},
+	}
+	if _, err := o.KClient.Services(o.Project).Create(srv); err != nil && !kapierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil {
+			continue
+		}
+		if _, err := updateAndDeploy(o.Client, o.Project, dc); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(o.Out, "metrics enabled for cluster %q on port %d of service %s\n", o.Name, metricsPort, name)
+	return nil
+}
+
+func (o *MetricsOptions) RunMetrics() error {
+	if _, _, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name); err != nil {
+		return err
+	}
+	masters, err := podMetrics(o.KClient, o.Project, o.Name, masterType)
+	if err != nil {
+		return err
+	}
+	workers, err := podMetrics(o.KClient, o.Project, o.Name, workerType)
+	if err != nil {
+		return err
+	}
+
+	w := kubectl.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	sum := func(all []map[string]float64, name string) float64 {
+		total := 0.0
+		for _, m := range all {
+			total += m[name]
+		}
+		return total
+	}
+	fmt.Fprintf(w, "Alive workers:\t%.0f\n", sum(masters, "spark_master_aliveworkers"))
+	fmt.Fprintf(w, "Cores used:\t%.0f of %.0f\n", sum(workers, "spark_worker_coresused"), sum(workers, "spark_worker_coresused")+sum(workers, "spark_worker_coresfree"))
+	fmt.Fprintf(w, "Memory used:\t%.0fMB of %.0fMB\n", sum(workers, "spark_worker_memused_mb"), sum(workers, "spark_worker_memused_mb")+sum(workers, "spark_worker_memfree_mb"))
+	fmt.Fprintf(w, "Daemon GC time:\t%.1fs\n", sum(masters, "jvm_gc_collection_seconds_sum")+sum(workers, "jvm_gc_collection_seconds_sum"))
+
+	state, err := getMasterState(o.KClient, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	pod, err := runningMasterPod(o.KClient, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	if len(state.ActiveApps) == 0 {
+		fmt.Fprintln(w, "Executor GC time:\tno running applications")
+		return nil
+	}
+	fmt.Fprintln(w, "Executor GC time:")
+	for _, app := range state.ActiveApps {
+		gc, err := executorGCTime(o.KClient, o.Project, pod.Name, app.ID)
+		if err != nil {
+			fmt.Fprintf(w, "  %s (%s):\tunavailable\n", app.Name, app.ID)
+			continue
+		}
+		fmt.Fprintf(w, "  %s (%s):\t%v\n", app.Name, app.ID, gc)
+	}
+	return nil
+}
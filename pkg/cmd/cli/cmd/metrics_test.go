@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected map[string]float64
+	}{
+		{
+			name:     "empty",
+			data:     "",
+			expected: map[string]float64{},
+		},
+		{
+			name: "comments and blank lines",
+			data: `# HELP jvm_threads_current Current thread count
+# TYPE jvm_threads_current gauge
+
+jvm_threads_current 42
+`,
+			expected: map[string]float64{"jvm_threads_current": 42},
+		},
+		{
+			name: "samples summed over labels",
+			data: `jvm_memory_bytes_used{area="heap"} 1.5e+08
+jvm_memory_bytes_used{area="nonheap"} 5e+07
+`,
+			expected: map[string]float64{"jvm_memory_bytes_used": 2e+08},
+		},
+		{
+			name:     "label value with spaces",
+			data:     `metrics_executor_tasks{app="word count",state="active task"} 3`,
+			expected: map[string]float64{"metrics_executor_tasks": 3},
+		},
+		{
+			name:     "timestamp",
+			data:     "process_cpu_seconds_total 12.5 1500000000000",
+			expected: map[string]float64{"process_cpu_seconds_total": 12.5},
+		},
+		{
+			name: "invalid lines skipped",
+			data: `no_value
+bad_value{a="b"} many
+good 1
+`,
+			expected: map[string]float64{"good": 1},
+		},
+	}
+
+	for _, test := range tests {
+		if got := parseMetrics([]byte(test.data)); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
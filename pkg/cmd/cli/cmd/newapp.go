@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kapierrors "k8s.io/kubernetes/pkg/api/errors"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/kubectl/resource"
+	kutilerrors "k8s.io/kubernetes/pkg/util/errors"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/admin/policy"
+	ocmd "github.com/openshift/origin/pkg/cmd/cli/cmd"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	configcmd "github.com/openshift/origin/pkg/config/cmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	newcmd "github.com/openshift/origin/pkg/generate/app/cmd"
+	outil "github.com/openshift/origin/pkg/util"
+)
+
+const (
+	newAppLong = `
+Build a spark application from source and deploy its driver.
+
+The source repository is built with a spark aware S2I builder image into an
+application image, and a deployment config running the driver is created,
+the same way new-app does. The builder image runs --app-file with --app-args
+through spark-submit, adding --spark-options.
+
+With --cluster the driver is linked to an existing cluster, see link. With
+--ephemeral the driver creates a cluster of its own when it starts and deletes
+it when the application completes. It runs as the service account
+--service-account, which is created when missing and given the edit role in
+the project before anything else is created.`
+
+	newAppExample = `  # Build the application in a git repository and run it on the cluster 'mycluster'
+  %[1]s new-app https://github.com/radanalyticsio/tutorial-sparkpi-python-flask --cluster mycluster
+
+  # Build a java application and run it on a cluster of its own
+  %[1]s new-app https://github.com/example/etl --ephemeral --builder-image radanalyticsio/radanalytics-java-spark --app-file etl.jar`
+)
+
+const (
+	defaultBuilderImage = "radanalyticsio/radanalytics-pyspark"
+	defaultDriverSA     = "oshinko"
+
+	// driverRole lets an ephemeral driver create and delete its cluster
+	driverRole = "edit"
+
+	appFileEnv       = "APP_FILE"
+	appArgsEnv       = "APP_ARGS"
+	sparkOptionsEnv  = "SPARK_OPTIONS"
+	deleteClusterEnv = "OSHINKO_DEL_CLUSTER"
+
+	generatedByOshinko = "OshinkoNewApp"
+)
+
+// ensureDriverServiceAccount creates the service account of ephemeral drivers
+// when it is missing and gives it the edit role in the project
+func ensureDriverServiceAccount(oClient *client.Client, kClient *kclient.Client, namespace, name string) error {
+	sa := &kapi.ServiceAccount{ObjectMeta: kapi.ObjectMeta{Name: name}}
+	if _, err := kClient.ServiceAccounts(namespace).Create(sa); err != nil && !kapierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("unable to create the service account %s of the driver: %v", name, err)
+	}
+
+	subject := kapi.ObjectReference{Kind: "ServiceAccount", Namespace: namespace, Name: name}
+	accessor := policy.NewLocalRoleBindingAccessor(namespace, oClient)
+	bindings, err := accessor.GetExistingRoleBindingsForRole("", driverRole)
+	if err == nil {
+		for _, binding := range bindings {
+			for _, s := range binding.Subjects {
+				if s.Kind == subject.Kind && s.Name == subject.Name && (s.Namespace == "" || s.Namespace == namespace) {
+					return nil
+				}
+			}
+		}
+		grant := &policy.RoleModificationOptions{
+			RoleName:            driverRole,
+			RoleBindingAccessor: accessor,
+			Subjects:            []kapi.ObjectReference{subject},
+		}
+		err = grant.AddRole()
+	}
+	if err != nil {
+		return fmt.Errorf("unable to give the service account %s the %s role, a project admin can run 'oc policy add-role-to-user %s -z %s': %v", name, driverRole, driverRole, name, err)
+	}
+	return nil
+}
+
+type NewAppOptions struct {
+	ClusterCmdOptions
+
+	Source         string
+	Cluster        string
+	Ephemeral      bool
+	BuilderImage   string
+	AppName        string
+	ContextDir     string
+	AppFile        string
+	AppArgs        string
+	SparkOptions   string
+	Env            []string
+	ServiceAccount string
+
+	config *newcmd.AppConfig
+	mapper *resource.Mapper
+}
+
+// NewCmdNewApp implements the oshinko new-app command
+func NewCmdNewApp(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &NewAppOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "new-app <SOURCE> (--cluster <NAME> | --ephemeral)",
+		Short:   "Build a spark application from source and deploy its driver",
+		Long:    newAppLong,
+		Example: fmt.Sprintf(newAppExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, cmd, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunNewApp(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Cluster, "cluster", "", "The cluster the driver submits the application to")
+	cmd.Flags().BoolVar(&options.Ephemeral, "ephemeral", false, "If true, the driver runs the application on a cluster of its own")
+	cmd.Flags().StringVar(&options.BuilderImage, "builder-image", defaultBuilderImage, "The spark aware S2I builder image")
+	cmd.Flags().StringVar(&options.AppName, "name", "", "The name of the generated objects, derived from the source if empty")
+	cmd.Flags().StringVar(&options.ContextDir, "context-dir", "", "The directory of the application in the source repository")
+	cmd.Flags().StringVar(&options.AppFile, "app-file", "", "The file of the application to submit, detected by the builder image if empty")
+	cmd.Flags().StringVar(&options.AppArgs, "app-args", "", "The arguments of the application")
+	cmd.Flags().StringVar(&options.SparkOptions, "spark-options", "", "Options added to spark-submit")
+	cmd.Flags().StringSliceVarP(&options.Env, "env", "e", []string{}, "Environment variables of the driver as KEY=VALUE")
+	cmd.Flags().StringVar(&options.ServiceAccount, "service-account", defaultDriverSA, "The service account of an ephemeral driver")
+	return cmd
+}
+
+func (o *NewAppOptions) Complete(f *clientcmd.Factory, cmd *cobra.Command, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("a single source repository must be specified")
+	}
+	o.Source = args[0]
+	if (o.Cluster == "") == !o.Ephemeral {
+		return fmt.Errorf("either --cluster or --ephemeral must be specified")
+	}
+	if err := o.completeClients(f, out); err != nil {
+		return err
+	}
+	o.Name = o.Cluster
+
+	config := newcmd.NewAppConfig()
+	config.Deploy = true
+	config.Strategy = "source"
+	config.Name = o.AppName
+	config.ContextDir = o.ContextDir
+	config.Out, config.ErrOut = out, cmd.Out()
+	config.Environment = append(config.Environment, o.Env...)
+	for _, env := range [][2]string{{appFileEnv, o.AppFile}, {appArgsEnv, o.AppArgs}, {sparkOptionsEnv, o.SparkOptions}} {
+		if env[1] != "" {
+			config.Environment = append(config.Environment, env[0]+"="+env[1])
+		}
+	}
+	if o.Ephemeral {
+		config.Environment = append(config.Environment, deleteClusterEnv+"=true")
+	}
+	if err := ocmd.CompleteAppConfig(config, f, cmd, []string{o.BuilderImage + "~" + o.Source}); err != nil {
+		return err
+	}
+	o.config = config
+	o.mapper = clientcmd.ResourceMapper(f)
+	return nil
+}
+
+func (o *NewAppOptions) RunNewApp() error {
+	var master *deployapi.DeploymentConfig
+	if o.Ephemeral {
+		if err := ensureDriverServiceAccount(o.Client, o.KClient, o.Project, o.ServiceAccount); err != nil {
+			return err
+		}
+	}
+	if o.Cluster != "" {
+		var err error
+		if master, _, err = clusterDeploymentConfigs(o.Client, o.Project, o.Cluster); err != nil {
+			return err
+		}
+		if master == nil {
+			return fmt.Errorf("cluster %q has no master", o.Cluster)
+		}
+	}
+
+	result, err := o.config.Run()
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{"app": result.Name}
+	var driver *deployapi.DeploymentConfig
+	for _, item := range result.List.Items {
+		if err := outil.AddObjectLabels(item, labels); err != nil {
+			return err
+		}
+		if err := outil.AddObjectAnnotations(item, map[string]string{newcmd.GeneratedByNamespace: generatedByOshinko}); err != nil {
+			return err
+		}
+		if dc, ok := item.(*deployapi.DeploymentConfig); ok {
+			driver = dc
+		}
+	}
+	if driver == nil || sparkContainer(driver.Spec.Template) == nil {
+		return fmt.Errorf("no driver deployment config was generated from %s", o.Source)
+	}
+
+	if master != nil {
+		if _, err := linkTemplate(o.KClient, o.Project, o.Cluster, master, driver); err != nil {
+			return err
+		}
+	} else {
+		driver.Spec.Template.Spec.ServiceAccountName = o.ServiceAccount
+	}
+	if err := checkCapacity(o.KClient, o.Project, []podShape{{driver.Spec.Template, driver.Spec.Replicas}}); err != nil {
+		return err
+	}
+
+	action := configcmd.BulkAction{
+		Bulk:   configcmd.Bulk{Mapper: o.mapper, Op: configcmd.Create},
+		Out:    o.Out,
+		ErrOut: o.config.ErrOut,
+	}
+	if errs := action.WithMessage(configcmd.CreateMessage(labels), "created").Run(result.List, result.Namespace); len(errs) > 0 {
+		return kutilerrors.NewAggregate(errs)
+	}
+
+	if master != nil {
+		if err := recordLink(o.Client, o.Project, master, driver.Name); err != nil {
+			return err
+		}
+		fmt.Fprintf(o.Out, "driver %s linked to cluster %q\n", driver.Name, o.Cluster)
+	}
+	return nil
+}
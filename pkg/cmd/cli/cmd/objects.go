@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/util/intstr"
+
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+// masterAddressEnv tells a spark worker where to find its master
+const masterAddressEnv = "SPARK_MASTER_ADDRESS"
+
+// masterUIAddressEnv tells a spark worker where the master web ui is
+const masterUIAddressEnv = "SPARK_MASTER_UI_ADDRESS"
+
+func masterServiceName(clustername string) string {
+	return clustername
+}
+
+func webuiServiceName(clustername string) string {
+	return clustername + "-ui"
+}
+
+// masterAddress is the spark url of the master service of a cluster
+func masterAddress(clustername string) string {
+	return fmt.Sprintf("spark://%s:%d", masterServiceName(clustername), masterPort)
+}
+
+func masterDeploymentConfigName(clustername string) string {
+	return clustername + "-m"
+}
+
+func clusterLabels(otype, clustername string) map[string]string {
+	return map[string]string{typeLabel: otype, clusterLabel: clustername}
+}
+
+// newClusterService builds a service exposing one port of the master of a cluster
+func newClusterService(name, clustername, otype, portname string, port int) *kapi.Service {
+	return &kapi.Service{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   name,
+			Labels: clusterLabels(otype, clustername),
+		},
+		Spec: kapi.ServiceSpec{
+			Selector: clusterLabels(masterType, clustername),
+			Ports: []kapi.ServicePort{
+				{
+					Name:       portname,
+					Protocol:   kapi.ProtocolTCP,
+					Port:       port,
+					TargetPort: intstr.FromInt(port),
+				},
+			},
+		},
+	}
+}
+
+// newMasterDeploymentConfig builds a master deployment config for a cluster
+// from its worker deployment config, so both run the same image and settings
+func newMasterDeploymentConfig(clustername string, worker *deployapi.DeploymentConfig) (*deployapi.DeploymentConfig, error) {
+	copied, err := kapi.Scheme.DeepCopy(worker)
+	if err != nil {
+		return nil, err
+	}
+	dc := copied.(*deployapi.DeploymentConfig)
+	name := masterDeploymentConfigName(clustername)
+
+	dc.ObjectMeta = kapi.ObjectMeta{
+		Name:   name,
+		Labels: clusterLabels(masterType, clustername),
+	}
+	dc.Status = deployapi.DeploymentConfigStatus{}
+	dc.Spec.Replicas = 1
+	dc.Spec.Selector = clusterLabels(masterType, clustername)
+	dc.Spec.Selector[deployapi.DeploymentConfigLabel] = name
+	dc.Spec.Template.Labels = dc.Spec.Selector
+
+	c := sparkContainer(dc.Spec.Template)
+	if c != nil {
+		env := []kapi.EnvVar{}
+		for _, e := range c.Env {
+			if e.Name != masterAddressEnv && e.Name != masterUIAddressEnv {
+				env = append(env, e)
+			}
+		}
+		c.Env = env
+		c.Ports = []kapi.ContainerPort{
+			{Name: masterPortName, ContainerPort: masterPort, Protocol: kapi.ProtocolTCP},
+			{Name: webPortName, ContainerPort: webPort, Protocol: kapi.ProtocolTCP},
+		}
+	}
+	return dc, nil
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kapierrors "k8s.io/kubernetes/pkg/api/errors"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/util/sets"
+
+	"github.com/openshift/origin/pkg/client"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+// ClusterCmdOptions holds what every command acting on a single named
+// cluster needs: the cluster name, the target project and the clients.
+type ClusterCmdOptions struct {
+	Name    string
+	Project string
+	Client  *client.Client
+	KClient *kclient.Client
+	Out     io.Writer
+}
+
+func (o *ClusterCmdOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("a single cluster name must be specified")
+	}
+	o.Name = args[0]
+	return o.completeClients(f, out)
+}
+
+// servesResource tells whether the server provides a resource in an API
+// group version
+func servesResource(kClient *kclient.Client, groupVersion, resource string) (bool, error) {
+	resources, err := kClient.DiscoveryClient.ServerResourcesForGroupVersion(groupVersion)
+	if kapierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// reservedClusterNames would be taken for subcommands, such as top clusters,
+// if they named a cluster
+var reservedClusterNames = sets.NewString("clusters")
+
+// checkNewClusterName refuses names a new cluster cannot be given
+func checkNewClusterName(name string) error {
+	if reservedClusterNames.Has(name) {
+		return fmt.Errorf("%q is reserved and cannot name a cluster", name)
+	}
+	return nil
+}
+
+// completeClients sets up everything but the cluster name, for commands
+// which act on all clusters in a project
+func (o *ClusterCmdOptions) completeClients(f *clientcmd.Factory, out io.Writer) error {
+	var err error
+	o.Project, _, err = f.DefaultNamespace()
+	if err != nil {
+		return fmt.Errorf(nameSpaceMsg)
+	}
+
+	o.Client, o.KClient, err = f.Clients()
+	if err != nil {
+		return fmt.Errorf(clientMsg)
+	}
+
+	o.Out = out
+	return nil
+}
+
+// clusterDeploymentConfigs returns the master and worker deployment configs of
+// a cluster. It is an error for the cluster to have no deployment configs at all.
+func clusterDeploymentConfigs(oclient *client.Client, namespace, clustername string) (*deployapi.DeploymentConfig, *deployapi.DeploymentConfig, error) {
+	var master, worker *deployapi.DeploymentConfig
+	dcs, err := oclient.DeploymentConfigs(namespace).List(makeSelector("", clustername))
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range dcs.Items {
+		switch dcs.Items[i].Labels[typeLabel] {
+		case masterType:
+			master = &dcs.Items[i]
+		case workerType:
+			worker = &dcs.Items[i]
+		}
+	}
+	if master == nil && worker == nil {
+		return nil, nil, fmt.Errorf("cluster %q not found", clustername)
+	}
+	return master, worker, nil
+}
+
+// sparkContainer returns the first container of a pod template, which is the
+// spark daemon for every deployment config oshinko creates.
+func sparkContainer(template *kapi.PodTemplateSpec) *kapi.Container {
+	if template == nil || len(template.Spec.Containers) == 0 {
+		return nil
+	}
+	return &template.Spec.Containers[0]
+}
+
+// clusterNames returns the sorted names of all clusters in a project which
+// have at least one master or worker deployment config carrying the cluster label
+func clusterNames(oClient *client.Client, namespace string) ([]string, error) {
+	dcs, err := oClient.DeploymentConfigs(namespace).List(makeSelector("", ""))
+	if err != nil {
+		return nil, err
+	}
+	names := sets.NewString()
+	for i := range dcs.Items {
+		otype := dcs.Items[i].Labels[typeLabel]
+		if name, ok := dcs.Items[i].Labels[clusterLabel]; ok && (otype == masterType || otype == workerType) {
+			names.Insert(name)
+		}
+	}
+	return names.List(), nil
+}
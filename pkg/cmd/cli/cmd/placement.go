@@ -0,0 +1,361 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	placementLong = `
+Control the nodes the master and the workers of a cluster run on.
+
+--node-selector restricts the master and the workers to nodes carrying the
+given labels, an empty value removes the restriction. --toleration lets the
+pods run on nodes tainted with the given key, value and effect and can be
+repeated. It adds to the tolerations of the pods, replacing one with the same
+key and effect, --clear-tolerations removes them all. --spread-workers asks the
+scheduler to place the workers on different hosts when possible.
+
+The pods of the cluster are redeployed with the new placement.`
+
+	placementExample = `  # Run the cluster 'mycluster' on the analytics nodes and spread its workers
+  %[1]s placement mycluster --node-selector region=analytics --spread-workers
+
+  # Allow the pods on nodes tainted with dedicated=spark:NoSchedule
+  %[1]s placement mycluster --toleration dedicated=spark:NoSchedule`
+)
+
+// tolerationsAnnotation carries the tolerations of a pod as json until they
+// are a field of the pod spec
+const tolerationsAnnotation = "scheduler.alpha.kubernetes.io/tolerations"
+
+// podAntiAffinityKey is the field of the affinity annotation holding pod anti-affinity
+const podAntiAffinityKey = "podAntiAffinity"
+
+const hostnameTopologyKey = "kubernetes.io/hostname"
+
+type toleration struct {
+	Key      string `json:"key,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Effect   string `json:"effect,omitempty"`
+}
+
+func (t toleration) String() string {
+	s := t.Key
+	if t.Operator != "Exists" {
+		s += "=" + t.Value
+	}
+	if t.Effect != "" {
+		s += ":" + t.Effect
+	}
+	return s
+}
+
+type labelSelectorRequirement struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values,omitempty"`
+}
+
+type labelSelector struct {
+	MatchLabels      map[string]string          `json:"matchLabels,omitempty"`
+	MatchExpressions []labelSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+type podAffinityTerm struct {
+	LabelSelector *labelSelector `json:"labelSelector,omitempty"`
+	TopologyKey   string         `json:"topologyKey,omitempty"`
+}
+
+type weightedPodAffinityTerm struct {
+	Weight          int             `json:"weight"`
+	PodAffinityTerm podAffinityTerm `json:"podAffinityTerm"`
+}
+
+type podAntiAffinity struct {
+	Preferred []weightedPodAffinityTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+// parseToleration parses key[=value][:effect]
+func parseToleration(s string) (toleration, error) {
+	t := toleration{Operator: "Exists"}
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		t.Effect = s[i+1:]
+		s = s[:i]
+		if t.Effect != "NoSchedule" && t.Effect != "PreferNoSchedule" {
+			return t, fmt.Errorf("invalid toleration effect %q, must be NoSchedule or PreferNoSchedule", t.Effect)
+		}
+	}
+	if i := strings.Index(s, "="); i >= 0 {
+		t.Operator = "Equal"
+		t.Value = s[i+1:]
+		s = s[:i]
+	}
+	if s == "" {
+		return t, fmt.Errorf("a toleration must have a key")
+	}
+	t.Key = s
+	return t, nil
+}
+
+// parseNodeSelector parses key=value[,key=value...]
+func parseNodeSelector(s string) (map[string]string, error) {
+	selector := map[string]string{}
+	if s == "" {
+		return selector, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid node selector %q, must be key=value", pair)
+		}
+		selector[kv[0]] = kv[1]
+	}
+	return selector, nil
+}
+
+func formatNodeSelector(selector map[string]string) string {
+	pairs := []string{}
+	for k, v := range selector {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func podTolerations(template *kapi.PodTemplateSpec) ([]toleration, error) {
+	tolerations := []toleration{}
+	if value := template.Annotations[tolerationsAnnotation]; value != "" {
+		if err := json.Unmarshal([]byte(value), &tolerations); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %v", tolerationsAnnotation, err)
+		}
+	}
+	return tolerations, nil
+}
+
+// mergeTolerations adds tolerations to existing ones, an added toleration
+// replaces an existing one with the same key and effect
+func mergeTolerations(existing, added []toleration) []toleration {
+	merged := []toleration{}
+	for _, e := range existing {
+		replaced := false
+		for _, a := range added {
+			if a.Key == e.Key && a.Effect == e.Effect {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, e)
+		}
+	}
+	for i, a := range added {
+		duplicate := false
+		for _, later := range added[i+1:] {
+			if later.Key == a.Key && later.Effect == a.Effect {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			merged = append(merged, a)
+		}
+	}
+	return merged
+}
+
+func setPodTolerations(template *kapi.PodTemplateSpec, tolerations []toleration) error {
+	if len(tolerations) == 0 {
+		delete(template.Annotations, tolerationsAnnotation)
+		return nil
+	}
+	data, err := json.Marshal(tolerations)
+	if err != nil {
+		return err
+	}
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[tolerationsAnnotation] = string(data)
+	return nil
+}
+
+// podAffinity returns the fields of the affinity annotation of a pod template,
+// so node affinity set by other tools is kept when anti-affinity changes
+func podAffinity(template *kapi.PodTemplateSpec) (map[string]json.RawMessage, error) {
+	affinity := map[string]json.RawMessage{}
+	if value := template.Annotations[kapi.AffinityAnnotationKey]; value != "" {
+		if err := json.Unmarshal([]byte(value), &affinity); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %v", kapi.AffinityAnnotationKey, err)
+		}
+	}
+	return affinity, nil
+}
+
+// spreadsWorkers reports whether a pod template prefers hosts without other
+// workers of the same cluster
+func spreadsWorkers(template *kapi.PodTemplateSpec) bool {
+	affinity, err := podAffinity(template)
+	if err != nil {
+		return false
+	}
+	_, ok := affinity[podAntiAffinityKey]
+	return ok
+}
+
+func setSpreadWorkers(template *kapi.PodTemplateSpec, clustername string, spread bool) error {
+	affinity, err := podAffinity(template)
+	if err != nil {
+		return err
+	}
+	delete(affinity, podAntiAffinityKey)
+	if spread {
+		data, err := json.Marshal(podAntiAffinity{
+			Preferred: []weightedPodAffinityTerm{{
+				Weight: 100,
+				PodAffinityTerm: podAffinityTerm{
+					LabelSelector: &labelSelector{MatchLabels: clusterLabels(workerType, clustername)},
+					TopologyKey:   hostnameTopologyKey,
+				},
+			}},
+		})
+		if err != nil {
+			return err
+		}
+		affinity[podAntiAffinityKey] = data
+	}
+
+	if len(affinity) == 0 {
+		delete(template.Annotations, kapi.AffinityAnnotationKey)
+		return nil
+	}
+	data, err := json.Marshal(affinity)
+	if err != nil {
+		return err
+	}
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[kapi.AffinityAnnotationKey] = string(data)
+	return nil
+}
+
+type PlacementOptions struct {
+	ClusterCmdOptions
+
+	NodeSelector     string
+	Tolerations      []string
+	ClearTolerations bool
+	SpreadWorkers    bool
+
+	setNodeSelector  bool
+	setSpreadWorkers bool
+}
+
+// NewCmdPlacement implements the oshinko placement command
+func NewCmdPlacement(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &PlacementOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "placement <NAME>",
+		Short:   "Control the nodes a cluster runs on",
+		Long:    placementLong,
+		Example: fmt.Sprintf(placementExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			options.setNodeSelector = cmd.Flags().Changed("node-selector")
+			options.setSpreadWorkers = cmd.Flags().Changed("spread-workers")
+
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunPlacement(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.NodeSelector, "node-selector", "", "Labels of the nodes to run on as key=value[,key=value], empty to run anywhere")
+	cmd.Flags().StringSliceVar(&options.Tolerations, "toleration", []string{}, "A taint to tolerate as key[=value][:effect], may be repeated")
+	cmd.Flags().BoolVar(&options.ClearTolerations, "clear-tolerations", false, "If true, remove all tolerations")
+	cmd.Flags().BoolVar(&options.SpreadWorkers, "spread-workers", false, "If true, prefer placing the workers on different hosts")
+	return cmd
+}
+
+func (o *PlacementOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if err := o.ClusterCmdOptions.Complete(f, args, out); err != nil {
+		return err
+	}
+	if !o.setNodeSelector && !o.setSpreadWorkers && len(o.Tolerations) == 0 && !o.ClearTolerations {
+		return fmt.Errorf("at least one of --node-selector, --toleration, --clear-tolerations or --spread-workers must be specified")
+	}
+	if len(o.Tolerations) > 0 && o.ClearTolerations {
+		return fmt.Errorf("--toleration and --clear-tolerations cannot be used together")
+	}
+	return nil
+}
+
+func (o *PlacementOptions) RunPlacement() error {
+	selector, err := parseNodeSelector(o.NodeSelector)
+	if err != nil {
+		return err
+	}
+	tolerations := []toleration{}
+	for _, s := range o.Tolerations {
+		t, err := parseToleration(s)
+		if err != nil {
+			return err
+		}
+		tolerations = append(tolerations, t)
+	}
+
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil {
+			continue
+		}
+		template := dc.Spec.Template
+		if o.setNodeSelector {
+			template.Spec.NodeSelector = selector
+		}
+		if o.ClearTolerations {
+			if err := setPodTolerations(template, nil); err != nil {
+				return err
+			}
+		}
+		if len(tolerations) > 0 {
+			existing, err := podTolerations(template)
+			if err != nil {
+				return err
+			}
+			if err := setPodTolerations(template, mergeTolerations(existing, tolerations)); err != nil {
+				return err
+			}
+		}
+		if o.setSpreadWorkers && dc == worker {
+			if err := setSpreadWorkers(template, o.Name, o.SpreadWorkers); err != nil {
+				return err
+			}
+		}
+		if _, err := updateAndDeploy(o.Client, o.Project, dc); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(o.Out, "placement of cluster %q updated\n", o.Name)
+	return nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseToleration(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  toleration
+		expectErr bool
+	}{
+		{value: "dedicated", expected: toleration{Key: "dedicated", Operator: "Exists"}},
+		{value: "dedicated=spark", expected: toleration{Key: "dedicated", Operator: "Equal", Value: "spark"}},
+		{value: "dedicated=spark:NoSchedule", expected: toleration{Key: "dedicated", Operator: "Equal", Value: "spark", Effect: "NoSchedule"}},
+		{value: "dedicated:PreferNoSchedule", expected: toleration{Key: "dedicated", Operator: "Exists", Effect: "PreferNoSchedule"}},
+		{value: "dedicated=:NoSchedule", expected: toleration{Key: "dedicated", Operator: "Equal", Effect: "NoSchedule"}},
+		{value: "dedicated=spark:NoExecute", expectErr: true},
+		{value: "=spark", expectErr: true},
+		{value: ":NoSchedule", expectErr: true},
+		{value: "", expectErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := parseToleration(test.value)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %#v", test.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.value, err)
+			continue
+		}
+		if got != test.expected {
+			t.Errorf("%q: expected %#v, got %#v", test.value, test.expected, got)
+		}
+		if got.String() != test.value {
+			t.Errorf("%q: formatted back as %q", test.value, got.String())
+		}
+	}
+}
+
+func TestParseNodeSelector(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{value: "", expected: map[string]string{}},
+		{value: "region=east", expected: map[string]string{"region": "east"}},
+		{value: "region=east,disk=ssd", expected: map[string]string{"region": "east", "disk": "ssd"}},
+		{value: "role=", expected: map[string]string{"role": ""}},
+		{value: "region", expectErr: true},
+		{value: "=east", expectErr: true},
+		{value: "region=east,", expectErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := parseNodeSelector(test.value)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %v", test.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.value, test.expected, got)
+		}
+	}
+}
+
+func TestMergeTolerations(t *testing.T) {
+	dedicated := toleration{Key: "dedicated", Operator: "Equal", Value: "spark", Effect: "NoSchedule"}
+	gpu := toleration{Key: "gpu", Operator: "Exists"}
+
+	tests := []struct {
+		name            string
+		existing, added []toleration
+		expected        []toleration
+	}{
+		{
+			name:     "added to existing",
+			existing: []toleration{dedicated},
+			added:    []toleration{gpu},
+			expected: []toleration{dedicated, gpu},
+		},
+		{
+			name:     "same key and effect replaced",
+			existing: []toleration{dedicated, gpu},
+			added:    []toleration{{Key: "dedicated", Operator: "Equal", Value: "etl", Effect: "NoSchedule"}},
+			expected: []toleration{gpu, {Key: "dedicated", Operator: "Equal", Value: "etl", Effect: "NoSchedule"}},
+		},
+		{
+			name:     "same key other effect kept",
+			existing: []toleration{dedicated},
+			added:    []toleration{{Key: "dedicated", Operator: "Exists", Effect: "PreferNoSchedule"}},
+			expected: []toleration{dedicated, {Key: "dedicated", Operator: "Exists", Effect: "PreferNoSchedule"}},
+		},
+		{
+			name:     "last of repeated added wins",
+			added:    []toleration{gpu, {Key: "gpu", Operator: "Equal", Value: "k80"}},
+			expected: []toleration{{Key: "gpu", Operator: "Equal", Value: "k80"}},
+		},
+	}
+
+	for _, test := range tests {
+		if got := mergeTolerations(test.existing, test.added); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,525 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kapierrors "k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/v1"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmd "k8s.io/kubernetes/pkg/kubectl/cmd"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/util/sets"
+	"k8s.io/kubernetes/pkg/util/wait"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	pythonDepsLong = `
+Install python requirements into the master and the workers of a cluster.
+
+The requirements file is stored in the config map <NAME>-python-deps. An init
+container running the spark image installs it with pip into a volume shared
+with the spark container when a master or worker pod starts, and the volume is
+added to PYTHONPATH, so every pod gets the requirements, including the workers
+added later. Init containers need Kubernetes 1.3 or later.
+
+Once the pods are rolled out the versions installed in each of them are
+compared and the packages whose versions differ between pods, or which are
+missing from some pods, are reported. Pin the versions in the requirements
+file to keep the pods consistent.
+
+Without --requirement the installed versions are only checked, --remove takes
+the requirements out of the cluster.`
+
+	pythonDepsExample = `  # Install the requirements of an application into the cluster 'mycluster'
+  %[1]s python-deps mycluster -r requirements.txt
+
+  # Check the python packages of the cluster 'mycluster' are consistent
+  %[1]s python-deps mycluster`
+)
+
+const (
+	pythonDepsType          = "python-deps"
+	pythonDepsContainerName = "python-deps"
+	requirementsFile        = "requirements.txt"
+
+	requirementsVolumeName = "python-requirements"
+	requirementsMountPath  = "/etc/oshinko-python-deps"
+	pythonDepsVolumeName   = "python-deps"
+	pythonDepsMountPath    = "/opt/python-deps"
+	pythonPathEnv          = "PYTHONPATH"
+	missingPackage         = "<missing>"
+
+	// pythonDepsAnnotation holds the hash of the requirements installed in a
+	// pod, it changes the pod template when the requirements change
+	pythonDepsAnnotation = "oshinko-python-deps"
+
+	// initContainersAnnotation declares the init containers of a pod in the
+	// Kubernetes releases whose pod spec has no init containers field yet.
+	// Kubernetes 1.3 reads the alpha annotation, later releases the beta one.
+	initContainersAnnotation      = "pod.beta.kubernetes.io/init-containers"
+	alphaInitContainersAnnotation = "pod.alpha.kubernetes.io/init-containers"
+)
+
+func pythonDepsName(clustername string) string {
+	return clustername + "-python-deps"
+}
+
+// requirementNames returns the normalized names of the packages listed in a
+// requirements file, options and references to other files are skipped
+func requirementNames(requirements string) sets.String {
+	names := sets.NewString()
+	for _, line := range strings.Split(requirements, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if i := strings.IndexAny(line, "=<>!~[;@ \t"); i >= 0 {
+			line = line[:i]
+		}
+		names.Insert(normalizePackageName(line))
+	}
+	return names
+}
+
+// normalizePackageName makes the spellings pip accepts for a package compare equal
+func normalizePackageName(name string) string {
+	return strings.Replace(strings.ToLower(name), "_", "-", -1)
+}
+
+// parsePipFreeze returns the versions of the packages listed by pip freeze
+func parsePipFreeze(out string) map[string]string {
+	versions := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "==", 2)
+		if len(parts) == 2 {
+			versions[normalizePackageName(parts[0])] = parts[1]
+		}
+	}
+	return versions
+}
+
+// setInitContainer adds a container to the init containers of a pod template,
+// replacing an init container of the same name
+func setInitContainer(template *kapi.PodTemplateSpec, container v1.Container) error {
+	containers, err := removeInitContainer(template, container.Name)
+	if err != nil {
+		return err
+	}
+	containers = append(containers, container)
+	value, err := json.Marshal(containers)
+	if err != nil {
+		return err
+	}
+	if template.Annotations == nil {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations[initContainersAnnotation] = string(value)
+	template.Annotations[alphaInitContainersAnnotation] = string(value)
+	return nil
+}
+
+// initContainers returns the init containers of a pod template
+func initContainers(template *kapi.PodTemplateSpec) ([]v1.Container, error) {
+	containers := []v1.Container{}
+	value := template.Annotations[initContainersAnnotation]
+	if value == "" {
+		value = template.Annotations[alphaInitContainersAnnotation]
+	}
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &containers); err != nil {
+			return nil, fmt.Errorf("invalid init containers annotation: %v", err)
+		}
+	}
+	return containers, nil
+}
+
+// removeInitContainer removes an init container from a pod template and
+// returns the remaining ones
+func removeInitContainer(template *kapi.PodTemplateSpec, name string) ([]v1.Container, error) {
+	containers, err := initContainers(template)
+	if err != nil {
+		return nil, err
+	}
+	kept := []v1.Container{}
+	for _, c := range containers {
+		if c.Name != name {
+			kept = append(kept, c)
+		}
+	}
+	delete(template.Annotations, initContainersAnnotation)
+	delete(template.Annotations, alphaInitContainersAnnotation)
+	if len(kept) > 0 {
+		value, err := json.Marshal(kept)
+		if err != nil {
+			return nil, err
+		}
+		template.Annotations[initContainersAnnotation] = string(value)
+		template.Annotations[alphaInitContainersAnnotation] = string(value)
+	}
+	return kept, nil
+}
+
+// installPythonDeps makes the pods of a deployment config install the
+// requirements stored in the python deps config map of the cluster
+func installPythonDeps(dc *deployapi.DeploymentConfig, clustername, hash string) error {
+	template := dc.Spec.Template
+	c := sparkContainer(template)
+	setVolume(template, kapi.Volume{
+		Name: requirementsVolumeName,
+		VolumeSource: kapi.VolumeSource{
+			ConfigMap: &kapi.ConfigMapVolumeSource{LocalObjectReference: kapi.LocalObjectReference{Name: pythonDepsName(clustername)}},
+		},
+	}, requirementsMountPath)
+	setVolume(template, kapi.Volume{
+		Name:         pythonDepsVolumeName,
+		VolumeSource: kapi.VolumeSource{EmptyDir: &kapi.EmptyDirVolumeSource{}},
+	}, pythonDepsMountPath)
+
+	// the spark image installs the packages so they match its python
+	err := setInitContainer(template, v1.Container{
+		Name:    pythonDepsContainerName,
+		Image:   c.Image,
+		Command: []string{"pip", "install", "--no-cache-dir", "--target", pythonDepsMountPath, "-r", requirementsMountPath + "/" + requirementsFile},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: requirementsVolumeName, MountPath: requirementsMountPath},
+			{Name: pythonDepsVolumeName, MountPath: pythonDepsMountPath},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if path := envValue(c, pythonPathEnv); path == "" {
+		setEnv(c, pythonPathEnv, pythonDepsMountPath)
+	} else if !sets.NewString(strings.Split(path, ":")...).Has(pythonDepsMountPath) {
+		setEnv(c, pythonPathEnv, pythonDepsMountPath+":"+path)
+	}
+	template.Annotations[pythonDepsAnnotation] = hash
+	return nil
+}
+
+// setPythonDepsImage makes the python deps init container of a pod template,
+// if any, run the given spark image
+func setPythonDepsImage(template *kapi.PodTemplateSpec, image string) error {
+	containers, err := initContainers(template)
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		if c.Name == pythonDepsContainerName {
+			c.Image = image
+			return setInitContainer(template, c)
+		}
+	}
+	return nil
+}
+
+// uninstallPythonDeps removes the settings of installPythonDeps from a deployment config
+func uninstallPythonDeps(dc *deployapi.DeploymentConfig) error {
+	template := dc.Spec.Template
+	if _, err := removeInitContainer(template, pythonDepsContainerName); err != nil {
+		return err
+	}
+	removeVolume(template, requirementsVolumeName)
+	removeVolume(template, pythonDepsVolumeName)
+
+	c := sparkContainer(template)
+	path := []string{}
+	for _, dir := range strings.Split(envValue(c, pythonPathEnv), ":") {
+		if dir != "" && dir != pythonDepsMountPath {
+			path = append(path, dir)
+		}
+	}
+	if len(path) == 0 {
+		removeEnv(c, pythonPathEnv)
+	} else {
+		setEnv(c, pythonPathEnv, strings.Join(path, ":"))
+	}
+	delete(template.Annotations, pythonDepsAnnotation)
+	return nil
+}
+
+// pythonDepsRolledOut reports whether the pods of a deployment config are all
+// ready and have installed the requirements with the given hash
+func pythonDepsRolledOut(kClient *kclient.Client, namespace, clustername, otype string, dc *deployapi.DeploymentConfig, hash string) (bool, error) {
+	pods, err := kClient.Pods(namespace).List(makeSelector(otype, clustername))
+	if err != nil {
+		return false, err
+	}
+	ready := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if pod.Annotations[pythonDepsAnnotation] != hash || pod.Status.Phase != kapi.PodRunning || !kapi.IsPodReady(pod) {
+			return false, nil
+		}
+		ready++
+	}
+	return ready == int(dc.Spec.Replicas), nil
+}
+
+type PythonDepsOptions struct {
+	ClusterCmdOptions
+
+	Requirement string
+	Remove      bool
+	Timeout     time.Duration
+
+	requirements string
+	factory      *clientcmd.Factory
+}
+
+// NewCmdPythonDeps implements the oshinko python-deps command
+func NewCmdPythonDeps(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &PythonDepsOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "python-deps <NAME> [-r <REQUIREMENTS FILE>]",
+		Short:   "Install python requirements into the pods of a cluster",
+		Long:    pythonDepsLong,
+		Example: fmt.Sprintf(pythonDepsExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunPythonDeps(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.Requirement, "requirement", "r", "", "The pip requirements file to install")
+	cmd.Flags().BoolVar(&options.Remove, "remove", false, "If true, remove the requirements from the cluster")
+	cmd.Flags().DurationVar(&options.Timeout, "timeout", 10*time.Minute, "How long to wait for the pods to install the requirements")
+	return cmd
+}
+
+func (o *PythonDepsOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if err := o.ClusterCmdOptions.Complete(f, args, out); err != nil {
+		return err
+	}
+	if o.Remove && o.Requirement != "" {
+		return fmt.Errorf("--requirement cannot be used with --remove")
+	}
+	if o.Requirement != "" {
+		data, err := ioutil.ReadFile(o.Requirement)
+		if err != nil {
+			return err
+		}
+		if requirementNames(string(data)).Len() == 0 {
+			return fmt.Errorf("%s lists no packages", o.Requirement)
+		}
+		o.requirements = string(data)
+	}
+	o.factory = f
+	return nil
+}
+
+func (o *PythonDepsOptions) RunPythonDeps() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	roles := map[string]*deployapi.DeploymentConfig{}
+	for otype, dc := range map[string]*deployapi.DeploymentConfig{masterType: master, workerType: worker} {
+		if dc == nil {
+			continue
+		}
+		if sparkContainer(dc.Spec.Template) == nil {
+			return fmt.Errorf("deployment config %s has no containers", dc.Name)
+		}
+		roles[otype] = dc
+	}
+
+	switch {
+	case o.Remove:
+		return o.removePythonDeps(roles)
+	case o.requirements != "":
+		if err := o.installPythonDeps(roles); err != nil {
+			return err
+		}
+	}
+	return o.verifyPythonDeps()
+}
+
+func (o *PythonDepsOptions) installPythonDeps(roles map[string]*deployapi.DeploymentConfig) error {
+	cm := &kapi.ConfigMap{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   pythonDepsName(o.Name),
+			Labels: clusterLabels(pythonDepsType, o.Name),
+		},
+		Data: map[string]string{requirementsFile: o.requirements},
+	}
+	if _, err := o.KClient.ConfigMaps(o.Project).Create(cm); kapierrors.IsAlreadyExists(err) {
+		_, err = o.KClient.ConfigMaps(o.Project).Update(cm)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(o.requirements)))[:16]
+	for _, dc := range roles {
+		if err := installPythonDeps(dc, o.Name, hash); err != nil {
+			return err
+		}
+		if _, err := updateAndDeploy(o.Client, o.Project, dc); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(o.Out, "waiting for the pods of cluster %q to install %s\n", o.Name, o.Requirement)
+
+	err := wait.PollImmediate(pollInterval, o.Timeout, func() (bool, error) {
+		for otype, dc := range roles {
+			done, err := pythonDepsRolledOut(o.KClient, o.Project, o.Name, otype, dc, hash)
+			if err != nil || !done {
+				return false, err
+			}
+		}
+		return true, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for the pods of cluster %q to install the requirements, see the logs of their %s init container", o.Name, pythonDepsContainerName)
+	}
+	return err
+}
+
+func (o *PythonDepsOptions) removePythonDeps(roles map[string]*deployapi.DeploymentConfig) error {
+	for _, dc := range roles {
+		if _, ok := dc.Spec.Template.Annotations[pythonDepsAnnotation]; !ok {
+			continue
+		}
+		if err := uninstallPythonDeps(dc); err != nil {
+			return err
+		}
+		if _, err := updateAndDeploy(o.Client, o.Project, dc); err != nil {
+			return err
+		}
+	}
+	if err := o.KClient.ConfigMaps(o.Project).Delete(pythonDepsName(o.Name)); err != nil && !kapierrors.IsNotFound(err) {
+		return err
+	}
+	fmt.Fprintf(o.Out, "python requirements removed from cluster %q\n", o.Name)
+	return nil
+}
+
+// podPackages runs pip freeze in a pod
+func (o *PythonDepsOptions) podPackages(config *kcmd.ExecOptions, podname string) (map[string]string, error) {
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	exec := *config
+	exec.PodName = podname
+	exec.Out, exec.Err = out, errOut
+	if err := exec.Run(); err != nil {
+		return nil, fmt.Errorf("unable to list the python packages of pod %s: %v %s", podname, err, strings.TrimSpace(errOut.String()))
+	}
+	return parsePipFreeze(out.String()), nil
+}
+
+// verifyPythonDeps compares the versions of the required packages installed
+// in the running pods of the cluster
+func (o *PythonDepsOptions) verifyPythonDeps() error {
+	cm, err := o.KClient.ConfigMaps(o.Project).Get(pythonDepsName(o.Name))
+	if kapierrors.IsNotFound(err) {
+		return fmt.Errorf("cluster %q has no python requirements, use --requirement", o.Name)
+	}
+	if err != nil {
+		return err
+	}
+	required := requirementNames(cm.Data[requirementsFile])
+
+	pods := []kapi.Pod{}
+	for _, otype := range []string{masterType, workerType} {
+		running, err := runningPods(o.KClient, o.Project, o.Name, otype)
+		if err != nil {
+			return err
+		}
+		pods = append(pods, running...)
+	}
+	if len(pods) == 0 {
+		return fmt.Errorf("no running pods found for cluster %q", o.Name)
+	}
+
+	clientConfig, err := o.factory.ClientConfig()
+	if err != nil {
+		return err
+	}
+	config := &kcmd.ExecOptions{
+		Namespace: o.Project,
+		Command:   []string{"pip", "freeze"},
+		Executor:  &kcmd.DefaultRemoteExecutor{},
+		Client:    o.KClient,
+		Config:    clientConfig,
+	}
+
+	// the pods having each version of each package
+	versions := make(map[string]map[string][]string)
+	for _, name := range required.List() {
+		versions[name] = make(map[string][]string)
+	}
+	for _, pod := range pods {
+		installed, err := o.podPackages(config, pod.Name)
+		if err != nil {
+			return err
+		}
+		for name := range versions {
+			version, ok := installed[name]
+			if !ok {
+				version = missingPackage
+			}
+			versions[name][version] = append(versions[name][version], pod.Name)
+		}
+	}
+
+	mismatched := []string{}
+	w := kubectl.GetNewTabWriter(o.Out)
+	fmt.Fprintln(w, "PACKAGE\tVERSION\tPODS")
+	for _, name := range required.List() {
+		if len(versions[name]) == 1 {
+			for version := range versions[name] {
+				fmt.Fprintf(w, "%s\t%s\tall %d\n", name, version, len(pods))
+				if version == missingPackage {
+					mismatched = append(mismatched, name)
+				}
+			}
+			continue
+		}
+		mismatched = append(mismatched, name)
+		list := []string{}
+		for version := range versions[name] {
+			list = append(list, version)
+		}
+		sort.Strings(list)
+		for _, version := range list {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", name, version, strings.Join(versions[name][version], ", "))
+		}
+	}
+	w.Flush()
+
+	if len(mismatched) > 0 {
+		return fmt.Errorf("python packages missing or differing between the pods of cluster %q: %s", o.Name, strings.Join(mismatched, ", "))
+	}
+	fmt.Fprintf(o.Out, "the python requirements of cluster %q are consistent across %d pods\n", o.Name, len(pods))
+	return nil
+}
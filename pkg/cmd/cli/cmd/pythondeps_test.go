@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequirementNames(t *testing.T) {
+	tests := []struct {
+		name         string
+		requirements string
+		expected     []string
+	}{
+		{
+			name:         "empty",
+			requirements: "",
+			expected:     []string{},
+		},
+		{
+			name: "version specifiers",
+			requirements: `numpy==1.13.1
+pandas>=0.20
+scipy
+requests[security]~=2.18
+six!=1.10.0
+`,
+			expected: []string{"numpy", "pandas", "requests", "scipy", "six"},
+		},
+		{
+			name: "comments, options and references skipped",
+			requirements: `# data science
+--index-url https://pypi.example.com/simple
+-r other-requirements.txt
+-e git+https://github.com/example/lib.git#egg=lib
+numpy  # pinned by the image
+`,
+			expected: []string{"numpy"},
+		},
+		{
+			name: "names normalized",
+			requirements: `Scikit_Learn==0.19.0
+PyYAML ; python_version < "3"
+attrs @ https://example.com/attrs.zip
+`,
+			expected: []string{"attrs", "pyyaml", "scikit-learn"},
+		},
+	}
+
+	for _, test := range tests {
+		if got := requirementNames(test.requirements).List(); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestParsePipFreeze(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		expected map[string]string
+	}{
+		{
+			name:     "empty",
+			out:      "",
+			expected: map[string]string{},
+		},
+		{
+			name: "pinned packages",
+			out: `numpy==1.13.1
+Scikit_Learn==0.19.0
+`,
+			expected: map[string]string{"numpy": "1.13.1", "scikit-learn": "0.19.0"},
+		},
+		{
+			name: "editable and direct references skipped",
+			out: `-e git+https://github.com/example/lib.git@abc123#egg=lib
+attrs @ file:///tmp/attrs
+six==1.10.0
+`,
+			expected: map[string]string{"six": "1.10.0"},
+		},
+	}
+
+	for _, test := range tests {
+		if got := parsePipFreeze(test.out); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
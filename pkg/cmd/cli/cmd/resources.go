@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/resource"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	resourcesLong = `
+Set the cpu and memory of the master and the workers of a cluster.
+
+The values are set as both the request and the limit of the spark container,
+so the pods are never throttled below or allowed above them. The spark
+settings are derived from the limits: SPARK_WORKER_CORES is the number of
+whole cpus, and the memory left after the daemon heap (SPARK_DAEMON_MEMORY)
+and a headroom for the JVM overhead of 10% or at least 384Mi is given to
+executors through SPARK_WORKER_MEMORY.`
+
+	resourcesExample = `  # Give each worker 2 cpus and 4Gi of memory and the master 1Gi
+  %[1]s resources mycluster --worker-cpu 2 --worker-memory 4Gi --master-memory 1Gi`
+)
+
+const (
+	workerCoresEnv  = "SPARK_WORKER_CORES"
+	workerMemoryEnv = "SPARK_WORKER_MEMORY"
+	daemonMemoryEnv = "SPARK_DAEMON_MEMORY"
+)
+
+const (
+	mebibyte = 1024 * 1024
+
+	// workerDaemonMemory is the heap of the worker daemon, which shares the
+	// container with the executors it starts
+	workerDaemonMemory = 256 * mebibyte
+
+	// minMemoryOverhead is the smallest headroom left for JVM overhead
+	minMemoryOverhead = 384 * mebibyte
+)
+
+// memoryOverhead is the part of a memory limit kept free for JVM overhead
+func memoryOverhead(limit int64) int64 {
+	if overhead := limit / 10; overhead > minMemoryOverhead {
+		return overhead
+	}
+	return minMemoryOverhead
+}
+
+// sparkMemory formats bytes the way spark expects memory settings
+func sparkMemory(bytes int64) string {
+	return fmt.Sprintf("%dm", bytes/mebibyte)
+}
+
+// roleResources are the cpu and memory given to the pods of one role
+type roleResources struct {
+	CPU    string
+	Memory string
+}
+
+func (r roleResources) isSet() bool {
+	return r.CPU != "" || r.Memory != ""
+}
+
+// apply sets the requests and limits of a container and returns the
+// resulting spark settings for the role
+func (r roleResources) apply(c *kapi.Container, otype string) (map[string]string, error) {
+	if c.Resources.Limits == nil {
+		c.Resources.Limits = kapi.ResourceList{}
+	}
+	if c.Resources.Requests == nil {
+		c.Resources.Requests = kapi.ResourceList{}
+	}
+	for name, value := range map[kapi.ResourceName]string{kapi.ResourceCPU: r.CPU, kapi.ResourceMemory: r.Memory} {
+		if value == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q for the %s: %v", name, value, otype, err)
+		}
+		c.Resources.Limits[name] = *q
+		c.Resources.Requests[name] = *q
+	}
+
+	settings := map[string]string{}
+	if cpu, ok := c.Resources.Limits[kapi.ResourceCPU]; ok && otype == workerType {
+		cores := cpu.MilliValue() / 1000
+		if cores < 1 {
+			cores = 1
+		}
+		settings[workerCoresEnv] = strconv.FormatInt(cores, 10)
+	}
+	if memory, ok := c.Resources.Limits[kapi.ResourceMemory]; ok {
+		available := memory.Value() - memoryOverhead(memory.Value())
+		if otype == workerType {
+			executors := available - workerDaemonMemory
+			if executors < mebibyte {
+				return nil, fmt.Errorf("a memory limit of %s leaves no memory for executors, at least %s is needed", memory.String(), sparkMemory(minMemoryOverhead+workerDaemonMemory+mebibyte))
+			}
+			settings[daemonMemoryEnv] = sparkMemory(workerDaemonMemory)
+			settings[workerMemoryEnv] = sparkMemory(executors)
+		} else {
+			if available < mebibyte {
+				return nil, fmt.Errorf("a memory limit of %s leaves no memory for the master heap, at least %s is needed", memory.String(), sparkMemory(minMemoryOverhead+mebibyte))
+			}
+			settings[daemonMemoryEnv] = sparkMemory(available)
+		}
+	}
+	return settings, nil
+}
+
+type ResourcesOptions struct {
+	ClusterCmdOptions
+
+	Master roleResources
+	Worker roleResources
+}
+
+// NewCmdResources implements the oshinko resources command
+func NewCmdResources(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &ResourcesOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "resources <NAME>",
+		Short:   "Set the cpu and memory of the master and the workers",
+		Long:    resourcesLong,
+		Example: fmt.Sprintf(resourcesExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunResources(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Master.CPU, "master-cpu", "", "The cpu request and limit of the master, e.g. 500m")
+	cmd.Flags().StringVar(&options.Master.Memory, "master-memory", "", "The memory request and limit of the master, e.g. 1Gi")
+	cmd.Flags().StringVar(&options.Worker.CPU, "worker-cpu", "", "The cpu request and limit of each worker, e.g. 2")
+	cmd.Flags().StringVar(&options.Worker.Memory, "worker-memory", "", "The memory request and limit of each worker, e.g. 4Gi")
+	return cmd
+}
+
+func (o *ResourcesOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if err := o.ClusterCmdOptions.Complete(f, args, out); err != nil {
+		return err
+	}
+	if !o.Master.isSet() && !o.Worker.isSet() {
+		return fmt.Errorf("at least one of --master-cpu, --master-memory, --worker-cpu or --worker-memory must be specified")
+	}
+	return nil
+}
+
+func (o *ResourcesOptions) RunResources() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+
+	// compute every change first so an invalid value leaves the cluster untouched
+	changed := []*deployapi.DeploymentConfig{}
+	shapes := []podShape{}
+	for _, role := range []struct {
+		dc        *deployapi.DeploymentConfig
+		otype     string
+		resources roleResources
+	}{
+		{master, masterType, o.Master},
+		{worker, workerType, o.Worker},
+	} {
+		if !role.resources.isSet() {
+			continue
+		}
+		if role.dc == nil {
+			return fmt.Errorf("cluster %q has no %s deployment config", o.Name, role.otype)
+		}
+		c := sparkContainer(role.dc.Spec.Template)
+		if c == nil {
+			return fmt.Errorf("deployment config %s has no containers", role.dc.Name)
+		}
+		previous, err := kapi.Scheme.DeepCopy(role.dc.Spec.Template)
+		if err != nil {
+			return err
+		}
+		settings, err := role.resources.apply(c, role.otype)
+		if err != nil {
+			return err
+		}
+		for name, value := range settings {
+			setEnv(c, name, value)
+		}
+		changed = append(changed, role.dc)
+		// the pods are replaced, only the difference counts against the quota
+		shapes = append(shapes,
+			podShape{role.dc.Spec.Template, role.dc.Spec.Replicas},
+			podShape{previous.(*kapi.PodTemplateSpec), -role.dc.Spec.Replicas})
+	}
+	if err := checkCapacity(o.KClient, o.Project, shapes); err != nil {
+		return err
+	}
+
+	for _, dc := range changed {
+		if _, err := updateAndDeploy(o.Client, o.Project, dc); err != nil {
+			return err
+		}
+		c := sparkContainer(dc.Spec.Template)
+		fmt.Fprintf(o.Out, "%s: cpu %s, memory %s", dc.Labels[typeLabel], limitString(c, kapi.ResourceCPU), limitString(c, kapi.ResourceMemory))
+		for _, name := range []string{workerCoresEnv, workerMemoryEnv, daemonMemoryEnv} {
+			if v := envValue(c, name); v != "" {
+				fmt.Fprintf(o.Out, ", %s=%s", name, v)
+			}
+		}
+		fmt.Fprintln(o.Out)
+	}
+	return nil
+}
+
+// limitString prints the limit of a container for a resource
+func limitString(c *kapi.Container, name kapi.ResourceName) string {
+	if q, ok := c.Resources.Limits[name]; ok {
+		return q.String()
+	}
+	return "unlimited"
+}
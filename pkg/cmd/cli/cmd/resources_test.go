@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/resource"
+)
+
+func TestMemoryOverhead(t *testing.T) {
+	tests := []struct {
+		limit    int64
+		expected int64
+	}{
+		{limit: 512 * mebibyte, expected: minMemoryOverhead},
+		{limit: 3840 * mebibyte, expected: minMemoryOverhead},
+		{limit: 10 * 1024 * mebibyte, expected: 1024 * mebibyte},
+	}
+
+	for _, test := range tests {
+		if overhead := memoryOverhead(test.limit); overhead != test.expected {
+			t.Errorf("limit %d: expected an overhead of %d, got %d", test.limit, test.expected, overhead)
+		}
+	}
+}
+
+func TestRoleResourcesApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources roleResources
+		otype     string
+		limits    kapi.ResourceList
+		expected  map[string]string
+		expectErr bool
+	}{
+		{
+			name:      "worker",
+			resources: roleResources{CPU: "2", Memory: "4Gi"},
+			otype:     workerType,
+			expected: map[string]string{
+				workerCoresEnv:  "2",
+				daemonMemoryEnv: "256m",
+				workerMemoryEnv: "3430m",
+			},
+		},
+		{
+			name:      "worker with less than a cpu",
+			resources: roleResources{CPU: "500m", Memory: "1Gi"},
+			otype:     workerType,
+			expected: map[string]string{
+				workerCoresEnv:  "1",
+				daemonMemoryEnv: "256m",
+				workerMemoryEnv: "384m",
+			},
+		},
+		{
+			name:      "worker without memory for executors",
+			resources: roleResources{Memory: "512Mi"},
+			otype:     workerType,
+			expectErr: true,
+		},
+		{
+			name:      "master",
+			resources: roleResources{CPU: "1", Memory: "1Gi"},
+			otype:     masterType,
+			expected:  map[string]string{daemonMemoryEnv: "640m"},
+		},
+		{
+			name:      "master without memory for the heap",
+			resources: roleResources{Memory: "384Mi"},
+			otype:     masterType,
+			expectErr: true,
+		},
+		{
+			name:     "existing limits",
+			otype:    masterType,
+			limits:   kapi.ResourceList{kapi.ResourceMemory: resource.MustParse("2Gi")},
+			expected: map[string]string{daemonMemoryEnv: "1664m"},
+		},
+		{
+			name:      "invalid cpu",
+			resources: roleResources{CPU: "two"},
+			otype:     workerType,
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		c := &kapi.Container{Resources: kapi.ResourceRequirements{Limits: test.limits}}
+		settings, err := test.resources.apply(c, test.otype)
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %v", test.name, settings)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(settings, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, settings)
+		}
+		for name, value := range map[kapi.ResourceName]string{kapi.ResourceCPU: test.resources.CPU, kapi.ResourceMemory: test.resources.Memory} {
+			if value == "" {
+				continue
+			}
+			limit, request := c.Resources.Limits[name], c.Resources.Requests[name]
+			if limit.Cmp(resource.MustParse(value)) != 0 || request.Cmp(limit) != 0 {
+				t.Errorf("%s: expected a %s request and limit of %s, got %s and %s", test.name, name, value, request.String(), limit.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+	deployutil "github.com/openshift/origin/pkg/deploy/util"
+)
+
+const (
+	rollbackLong = `
+Roll a cluster back to an earlier configuration revision.
+
+The master and the workers are rolled back together to the deployments which
+were current at the chosen revision, including images, replica counts and
+deployment strategies. If the workers cannot be rolled back, the master is
+returned to its current deployment. Without --to-revision the cluster returns to the
+revision before the current one. Use the history command to list revisions.`
+
+	rollbackExample = `  # Undo the last change to the cluster 'mycluster'
+  %[1]s rollback mycluster
+
+  # Return the cluster 'mycluster' to revision 3
+  %[1]s rollback mycluster --to-revision 3`
+)
+
+type RollbackOptions struct {
+	ClusterCmdOptions
+
+	ToRevision int
+}
+
+// NewCmdRollback implements the oshinko rollback command
+func NewCmdRollback(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &RollbackOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "rollback <NAME>",
+		Short:   "Roll a cluster back to an earlier revision",
+		Long:    rollbackLong,
+		Example: fmt.Sprintf(rollbackExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunRollback(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&options.ToRevision, "to-revision", 0, "The revision to roll back to, defaults to the previous revision")
+	return cmd
+}
+
+// rollbackConfig rolls a deployment config back to an earlier deployment
+// using the server side rollback generator. Automatic image triggers are
+// disabled so the old image is not immediately replaced again.
+func (o *RollbackOptions) rollbackConfig(dc *deployapi.DeploymentConfig, target *kapi.ReplicationController) error {
+	if target == nil || deployutil.DeploymentVersionFor(target) == dc.Status.LatestVersion {
+		return nil
+	}
+	rollback := &deployapi.DeploymentConfigRollback{
+		Spec: deployapi.DeploymentConfigRollbackSpec{
+			From: kapi.ObjectReference{
+				Name: target.Name,
+			},
+			IncludeTemplate:        true,
+			IncludeReplicationMeta: true,
+			IncludeStrategy:        true,
+		},
+	}
+	newConfig, err := o.Client.DeploymentConfigs(o.Project).Rollback(rollback)
+	if err != nil {
+		return err
+	}
+	// the rollback generator already moved to the next version
+	annotateChange(o.Client, newConfig, newConfig.Status.LatestVersion)
+	rolledback, err := o.Client.DeploymentConfigs(o.Project).Update(newConfig)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "%s #%d rolled back to %s\n", rolledback.Name, rolledback.Status.LatestVersion, target.Name)
+	return nil
+}
+
+func (o *RollbackOptions) RunRollback() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	if master == nil || worker == nil {
+		return fmt.Errorf("cluster %q is missing its master or worker deployment config", o.Name)
+	}
+	revisions, err := clusterRevisions(o.KClient, o.Project, master, worker)
+	if err != nil {
+		return err
+	}
+	if len(revisions) < 2 {
+		return fmt.Errorf("cluster %q has no earlier revision to roll back to", o.Name)
+	}
+
+	number := o.ToRevision
+	if number == 0 {
+		number = len(revisions) - 1
+	}
+	if number < 1 || number >= len(revisions) {
+		return fmt.Errorf("revision %d is not an earlier revision of cluster %q, see the history command", number, o.Name)
+	}
+	target := revisions[number-1]
+	current := revisions[len(revisions)-1]
+
+	if err := o.rollbackConfig(master, target.Master); err != nil {
+		return err
+	}
+	if err := o.rollbackConfig(worker, target.Worker); err != nil {
+		// take the master forward again so it matches the workers
+		master, restoreErr := o.Client.DeploymentConfigs(o.Project).Get(master.Name)
+		if restoreErr == nil {
+			restoreErr = o.rollbackConfig(master, current.Master)
+		}
+		if restoreErr != nil {
+			return fmt.Errorf("%v; restoring the master failed: %v", err, restoreErr)
+		}
+		return err
+	}
+	fmt.Fprintf(o.Out, "cluster %q rolled back to revision %d\n", o.Name, number)
+	return nil
+}
@@ -0,0 +1,680 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kapierrors "k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions"
+	"k8s.io/kubernetes/pkg/client/restclient"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/labels"
+
+	ocutil "github.com/openshift/origin/pkg/cmd/util"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	scheduleLong = `
+Run spark applications on a schedule.
+
+Each schedule is a CronJob, or a ScheduledJob on servers which predate
+CronJob, starting a driver at the times given in cron format. The driver runs spark-submit against a cluster, or an application image
+creating a cluster of its own. The jobs a schedule started, with their exit
+status, are shown by history.
+
+A schedule running on a cluster is listed as schedule/<NAME> among the linked
+applications of the cluster until it is deleted.`
+
+	scheduleCreateLong = `
+Create a schedule running a spark application.
+
+With --cluster the driver runs spark-submit in the spark image of the cluster,
+or --image, against the master of the cluster. The application and its
+arguments follow --, the application must be reachable from the driver, for
+example through a local:// path of a file copied to the pods.
+
+With --ephemeral --image must be an application image built with a spark aware
+builder, see new-app. It creates a cluster when it starts and deletes it when
+the application completes. It runs as the service account --service-account,
+which is created when missing and given the edit role in the project. The
+arguments following -- are the arguments of the application.
+
+A driver which fails is started again until it succeeds or the run lasted
+--deadline. Runs do not overlap, a run due while the previous one is still
+running is skipped.`
+
+	scheduleCreateExample = `  # Run the application etl.py every night at 2 on the cluster 'mycluster'
+  %[1]s schedule create nightly-etl --cron "0 2 * * *" --cluster mycluster -- local:///data/etl.py --day yesterday
+
+  # Run the application image 'etl' on a cluster of its own every hour
+  %[1]s schedule create hourly-etl --cron "0 * * * *" --ephemeral --image etl`
+
+	scheduleHistoryLong = `
+Show the recent runs of a schedule and how they ended.`
+)
+
+const (
+	// scheduleLabel carries the name of the schedule on its jobs and their pods
+	scheduleLabel = "oshinko-schedule"
+
+	// scheduleClusterAnnotation names the cluster of a schedule, or ephemeral
+	scheduleClusterAnnotation = "oshinko-schedule-cluster"
+	ephemeralCluster          = "ephemeral"
+
+	sparkSubmitPath = "/opt/spark/bin/spark-submit"
+)
+
+// scheduleAPI is a resource a schedule can be kept as, the vendored client
+// knows none of them
+type scheduleAPI struct {
+	GroupVersion string
+	Resource     string
+	Kind         string
+}
+
+var (
+	// cronJobAPIs are tried in order
+	cronJobAPIs = []scheduleAPI{
+		{"batch/v2alpha1", "cronjobs", "CronJob"},
+		{"batch/v1beta1", "cronjobs", "CronJob"},
+	}
+
+	// scheduledJobAPI is the name CronJob had before, on older servers
+	scheduledJobAPI = scheduleAPI{"batch/v2alpha1", "scheduledjobs", "ScheduledJob"}
+)
+
+// findScheduleAPI returns the resource the server keeps schedules as
+func findScheduleAPI(kClient *kclient.Client) (scheduleAPI, error) {
+	for _, api := range cronJobAPIs {
+		served, err := servesResource(kClient, api.GroupVersion, api.Resource)
+		if err != nil {
+			return api, err
+		}
+		if served {
+			return api, nil
+		}
+	}
+	return scheduledJobAPI, nil
+}
+
+// The types below are the parts of CronJob, and of ScheduledJob, used by oshinko
+
+type scheduledJobMeta struct {
+	Name              string            `json:"name"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	CreationTimestamp unversioned.Time  `json:"creationTimestamp,omitempty"`
+}
+
+type jobTemplate struct {
+	Metadata scheduledJobMeta `json:"metadata"`
+	Spec     json.RawMessage  `json:"spec"`
+}
+
+type scheduledJobSpec struct {
+	Schedule          string      `json:"schedule"`
+	ConcurrencyPolicy string      `json:"concurrencyPolicy,omitempty"`
+	JobTemplate       jobTemplate `json:"jobTemplate"`
+}
+
+type scheduledJobStatus struct {
+	LastScheduleTime *unversioned.Time `json:"lastScheduleTime,omitempty"`
+}
+
+type scheduledJob struct {
+	Kind       string             `json:"kind,omitempty"`
+	APIVersion string             `json:"apiVersion,omitempty"`
+	Metadata   scheduledJobMeta   `json:"metadata"`
+	Spec       scheduledJobSpec   `json:"spec"`
+	Status     scheduledJobStatus `json:"status,omitempty"`
+}
+
+type scheduledJobList struct {
+	Items []scheduledJob `json:"items"`
+}
+
+// jobSpec is the part of a batch/v1 JobSpec set by oshinko
+type jobSpec struct {
+	ActiveDeadlineSeconds *int64             `json:"activeDeadlineSeconds,omitempty"`
+	Template              v1.PodTemplateSpec `json:"template"`
+}
+
+// scheduleLinkName is the name a schedule is recorded under among the linked
+// applications of its cluster
+func scheduleLinkName(name string) string {
+	return "schedule/" + name
+}
+
+func scheduledJobsRequest(kClient *kclient.Client, api scheduleAPI, verb, namespace string, name ...string) *restclient.Request {
+	path := append([]string{"/apis", api.GroupVersion, "namespaces", namespace, api.Resource}, name...)
+	return kClient.Verb(verb).AbsPath(path...)
+}
+
+func getScheduledJob(kClient *kclient.Client, api scheduleAPI, namespace, name string) (*scheduledJob, error) {
+	body, err := scheduledJobsRequest(kClient, api, "GET", namespace, name).Do().Raw()
+	if err != nil {
+		return nil, err
+	}
+	job := &scheduledJob{}
+	if err := json.Unmarshal(body, job); err != nil {
+		return nil, err
+	}
+	if job.Metadata.Labels[scheduleLabel] == "" {
+		return nil, fmt.Errorf("%s %s was not created by oshinko", api.Kind, name)
+	}
+	return job, nil
+}
+
+// scheduleSelector selects the jobs and pods of one or all schedules
+func scheduleSelector(name string) labels.Selector {
+	if name == "" {
+		selector, _ := labels.Parse(scheduleLabel)
+		return selector
+	}
+	return labels.SelectorFromSet(labels.Set{scheduleLabel: name})
+}
+
+// jobState summarizes how a job ended
+func jobState(job *extensions.Job) string {
+	for _, c := range job.Status.Conditions {
+		if c.Status != kapi.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case extensions.JobComplete:
+			return "Succeeded"
+		case extensions.JobFailed:
+			return "Failed"
+		}
+	}
+	if job.Status.Active > 0 {
+		return "Running"
+	}
+	return "Pending"
+}
+
+// jobExitCode returns the exit code of the driver of the last pod of a job
+func jobExitCode(kClient *kclient.Client, namespace string, job *extensions.Job) string {
+	if job.Spec.Selector == nil {
+		return "-"
+	}
+	selector, err := unversioned.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return "-"
+	}
+	pods, err := kClient.Pods(namespace).List(kapi.ListOptions{LabelSelector: selector})
+	if err != nil || len(pods.Items) == 0 {
+		return "-"
+	}
+	sort.Sort(sort.Reverse(byPodCreation(pods.Items)))
+	for _, s := range pods.Items[0].Status.ContainerStatuses {
+		if s.State.Terminated != nil {
+			return fmt.Sprintf("%d", s.State.Terminated.ExitCode)
+		}
+	}
+	return "-"
+}
+
+// byPodCreation sorts pods from the oldest
+type byPodCreation []kapi.Pod
+
+func (p byPodCreation) Len() int      { return len(p) }
+func (p byPodCreation) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p byPodCreation) Less(i, j int) bool {
+	return p[i].CreationTimestamp.Before(p[j].CreationTimestamp)
+}
+
+// NewCmdSchedule implements the oshinko schedule command
+func NewCmdSchedule(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Run spark applications on a schedule",
+		Long:  scheduleLong,
+		Run:   ocutil.DefaultSubCommandRun(out),
+	}
+	cmd.AddCommand(NewCmdScheduleCreate(fullName, f, out))
+	cmd.AddCommand(NewCmdScheduleList(fullName, f, out))
+	cmd.AddCommand(NewCmdScheduleDelete(fullName, f, out))
+	cmd.AddCommand(NewCmdScheduleRunNow(fullName, f, out))
+	cmd.AddCommand(NewCmdScheduleHistory(fullName, f, out))
+	return cmd
+}
+
+type ScheduleOptions struct {
+	ClusterCmdOptions
+
+	Cron           string
+	Cluster        string
+	Ephemeral      bool
+	Image          string
+	ServiceAccount string
+	Deadline       time.Duration
+	Limit          int
+
+	app []string
+	api scheduleAPI
+}
+
+// NewCmdScheduleCreate implements the oshinko schedule create command
+func NewCmdScheduleCreate(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &ScheduleOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "create <NAME> --cron <SCHEDULE> (--cluster <CLUSTER> | --ephemeral) -- <APP> [ARGS...]",
+		Short:   "Create a schedule running a spark application",
+		Long:    scheduleCreateLong,
+		Example: fmt.Sprintf(scheduleCreateExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.completeCreate(f, cmd, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunScheduleCreate(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Cron, "cron", "", "When to run the application, in cron format")
+	cmd.Flags().StringVar(&options.Cluster, "cluster", "", "The cluster the application runs on")
+	cmd.Flags().BoolVar(&options.Ephemeral, "ephemeral", false, "If true, the application runs on a cluster of its own")
+	cmd.Flags().StringVar(&options.Image, "image", "", "The image of the driver, defaults to the spark image of the cluster")
+	cmd.Flags().StringVar(&options.ServiceAccount, "service-account", defaultDriverSA, "The service account of an ephemeral driver")
+	cmd.Flags().DurationVar(&options.Deadline, "deadline", 0, "How long a run may last, including retries, unlimited if 0")
+	return cmd
+}
+
+// NewCmdScheduleList implements the oshinko schedule list command
+func NewCmdScheduleList(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &ScheduleOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the schedules",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := options.completeClients(f, out)
+			if err == nil && len(args) > 0 {
+				err = fmt.Errorf("no arguments should be passed")
+			}
+			if err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunScheduleList(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	return cmd
+}
+
+// NewCmdScheduleDelete implements the oshinko schedule delete command
+func NewCmdScheduleDelete(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &ScheduleOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "delete <NAME>",
+		Short: "Delete a schedule and its jobs",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunScheduleDelete(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	return cmd
+}
+
+// NewCmdScheduleRunNow implements the oshinko schedule run-now command
+func NewCmdScheduleRunNow(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &ScheduleOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "run-now <NAME>",
+		Short: "Run the application of a schedule immediately",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunScheduleRunNow(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	return cmd
+}
+
+// NewCmdScheduleHistory implements the oshinko schedule history command
+func NewCmdScheduleHistory(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &ScheduleOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "history <NAME>",
+		Short: "Show the recent runs of a schedule",
+		Long:  scheduleHistoryLong,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunScheduleHistory(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().IntVar(&options.Limit, "limit", 10, "The number of runs to show")
+	return cmd
+}
+
+func (o *ScheduleOptions) completeCreate(f *clientcmd.Factory, cmd *cobra.Command, args []string, out io.Writer) error {
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		o.app = args[dash:]
+		args = args[:dash]
+	}
+	if len(args) != 1 {
+		return fmt.Errorf("a single schedule name must be specified")
+	}
+	if err := o.Complete(f, args, out); err != nil {
+		return err
+	}
+	if len(strings.Fields(o.Cron)) != 5 {
+		return fmt.Errorf("--cron must have five fields, minute hour day month weekday")
+	}
+	if (o.Cluster == "") == !o.Ephemeral {
+		return fmt.Errorf("either --cluster or --ephemeral must be specified")
+	}
+	if o.Ephemeral && o.Image == "" {
+		return fmt.Errorf("--image must be specified with --ephemeral")
+	}
+	if o.Cluster != "" && len(o.app) == 0 {
+		return fmt.Errorf("the application to submit must follow --")
+	}
+	return nil
+}
+
+// driverTemplate builds the pod template of the driver of a schedule
+func (o *ScheduleOptions) driverTemplate() (*kapi.PodTemplateSpec, error) {
+	template := &kapi.PodTemplateSpec{
+		ObjectMeta: kapi.ObjectMeta{Labels: map[string]string{scheduleLabel: o.Name}},
+		Spec: kapi.PodSpec{
+			RestartPolicy: kapi.RestartPolicyNever,
+			Containers:    []kapi.Container{{Name: "driver", Image: o.Image}},
+		},
+	}
+	c := sparkContainer(template)
+
+	if o.Ephemeral {
+		template.Spec.ServiceAccountName = o.ServiceAccount
+		setEnv(c, deleteClusterEnv, "true")
+		if len(o.app) > 0 {
+			setEnv(c, appArgsEnv, strings.Join(o.app, " "))
+		}
+		return template, nil
+	}
+
+	master, _, err := clusterDeploymentConfigs(o.Client, o.Project, o.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	if master == nil || sparkContainer(master.Spec.Template) == nil {
+		return nil, fmt.Errorf("cluster %q has no master", o.Cluster)
+	}
+	if c.Image == "" {
+		c.Image = sparkContainer(master.Spec.Template).Image
+	}
+	// linkTemplate works on deployment configs, lend it the pod template
+	app := &deployapi.DeploymentConfig{Spec: deployapi.DeploymentConfigSpec{Template: template}}
+	masterURL, err := linkTemplate(o.KClient, o.Project, o.Cluster, master, app)
+	if err != nil {
+		return nil, err
+	}
+	c.Command = append([]string{sparkSubmitPath, "--master", masterURL}, o.app...)
+	return template, nil
+}
+
+// findAPI finds the resource schedules are kept as
+func (o *ScheduleOptions) findAPI() error {
+	var err error
+	o.api, err = findScheduleAPI(o.KClient)
+	return err
+}
+
+func (o *ScheduleOptions) RunScheduleCreate() error {
+	if err := o.findAPI(); err != nil {
+		return err
+	}
+	if o.Ephemeral {
+		if err := ensureDriverServiceAccount(o.Client, o.KClient, o.Project, o.ServiceAccount); err != nil {
+			return err
+		}
+	}
+	template, err := o.driverTemplate()
+	if err != nil {
+		return err
+	}
+	if err := checkCapacity(o.KClient, o.Project, []podShape{{template, 1}}); err != nil {
+		return err
+	}
+	spec := jobSpec{}
+	if o.Deadline > 0 {
+		seconds := int64(o.Deadline.Seconds())
+		spec.ActiveDeadlineSeconds = &seconds
+	}
+	if err := kapi.Scheme.Convert(template, &spec.Template); err != nil {
+		return err
+	}
+	rawSpec, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	cluster := o.Cluster
+	if o.Ephemeral {
+		cluster = ephemeralCluster
+	}
+	job := &scheduledJob{
+		Kind:       o.api.Kind,
+		APIVersion: o.api.GroupVersion,
+		Metadata: scheduledJobMeta{
+			Name:        o.Name,
+			Labels:      map[string]string{scheduleLabel: o.Name},
+			Annotations: map[string]string{scheduleClusterAnnotation: cluster},
+		},
+		Spec: scheduledJobSpec{
+			Schedule:          o.Cron,
+			ConcurrencyPolicy: "Forbid",
+			JobTemplate: jobTemplate{
+				Metadata: scheduledJobMeta{Labels: map[string]string{scheduleLabel: o.Name}},
+				Spec:     rawSpec,
+			},
+		},
+	}
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if err := scheduledJobsRequest(o.KClient, o.api, "POST", o.Project).Body(body).Do().Error(); err != nil {
+		return err
+	}
+	if !o.Ephemeral {
+		master, _, err := clusterDeploymentConfigs(o.Client, o.Project, o.Cluster)
+		if err != nil {
+			return err
+		}
+		if err := recordLink(o.Client, o.Project, master, scheduleLinkName(o.Name)); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(o.Out, "schedule %s created, running at %q on %s\n", o.Name, o.Cron, cluster)
+	return nil
+}
+
+func (o *ScheduleOptions) RunScheduleList() error {
+	if err := o.findAPI(); err != nil {
+		return err
+	}
+	body, err := scheduledJobsRequest(o.KClient, o.api, "GET", o.Project).
+		Param("labelSelector", scheduleSelector("").String()).
+		Do().
+		Raw()
+	if err != nil {
+		return err
+	}
+	list := &scheduledJobList{}
+	if err := json.Unmarshal(body, list); err != nil {
+		return err
+	}
+	if len(list.Items) == 0 {
+		fmt.Fprintln(o.Out, "There are no schedules.")
+		return nil
+	}
+	sort.Sort(byScheduleName(list.Items))
+
+	w := kubectl.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	fmt.Fprintln(w, "NAME\tSCHEDULE\tCLUSTER\tLAST RUN")
+	for _, job := range list.Items {
+		last := "<never>"
+		if job.Status.LastScheduleTime != nil {
+			last = job.Status.LastScheduleTime.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", job.Metadata.Name, job.Spec.Schedule, job.Metadata.Annotations[scheduleClusterAnnotation], last)
+	}
+	return nil
+}
+
+type byScheduleName []scheduledJob
+
+func (s byScheduleName) Len() int           { return len(s) }
+func (s byScheduleName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byScheduleName) Less(i, j int) bool { return s[i].Metadata.Name < s[j].Metadata.Name }
+
+func (o *ScheduleOptions) RunScheduleDelete() error {
+	if err := o.findAPI(); err != nil {
+		return err
+	}
+	scheduled, err := getScheduledJob(o.KClient, o.api, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	if err := scheduledJobsRequest(o.KClient, o.api, "DELETE", o.Project, o.Name).Do().Error(); err != nil {
+		return err
+	}
+
+	// the cluster may be gone already
+	if cluster := scheduled.Metadata.Annotations[scheduleClusterAnnotation]; cluster != ephemeralCluster {
+		if master, _, err := clusterDeploymentConfigs(o.Client, o.Project, cluster); err == nil && master != nil {
+			if err := forgetLink(o.Client, o.Project, master, scheduleLinkName(o.Name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	jobs, err := o.KClient.Extensions().Jobs(o.Project).List(kapi.ListOptions{LabelSelector: scheduleSelector(o.Name)})
+	if err != nil {
+		return err
+	}
+	reaper, err := kubectl.ReaperFor(extensions.Kind("Job"), o.KClient)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs.Items {
+		if err := reaper.Stop(o.Project, job.Name, reapTimeout, nil); err != nil && !kapierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	fmt.Fprintf(o.Out, "schedule %s deleted with %d job(s)\n", o.Name, len(jobs.Items))
+	return nil
+}
+
+func (o *ScheduleOptions) RunScheduleRunNow() error {
+	if err := o.findAPI(); err != nil {
+		return err
+	}
+	scheduled, err := getScheduledJob(o.KClient, o.api, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-manual-%d", o.Name, time.Now().Unix())
+	job := map[string]interface{}{
+		"kind":       "Job",
+		"apiVersion": "batch/v1",
+		"metadata": scheduledJobMeta{
+			Name:   name,
+			Labels: scheduled.Spec.JobTemplate.Metadata.Labels,
+		},
+		"spec": scheduled.Spec.JobTemplate.Spec,
+	}
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if err := o.KClient.BatchClient.Post().Namespace(o.Project).Resource("jobs").Body(body).Do().Error(); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "job %s started\n", name)
+	return nil
+}
+
+type byJobCreation []extensions.Job
+
+func (s byJobCreation) Len() int      { return len(s) }
+func (s byJobCreation) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byJobCreation) Less(i, j int) bool {
+	return s[i].CreationTimestamp.Before(s[j].CreationTimestamp)
+}
+
+func (o *ScheduleOptions) RunScheduleHistory() error {
+	if err := o.findAPI(); err != nil {
+		return err
+	}
+	if _, err := getScheduledJob(o.KClient, o.api, o.Project, o.Name); err != nil {
+		return err
+	}
+	jobs, err := o.KClient.Extensions().Jobs(o.Project).List(kapi.ListOptions{LabelSelector: scheduleSelector(o.Name)})
+	if err != nil {
+		return err
+	}
+	if len(jobs.Items) == 0 {
+		fmt.Fprintf(o.Out, "schedule %s has not run yet\n", o.Name)
+		return nil
+	}
+	sort.Sort(sort.Reverse(byJobCreation(jobs.Items)))
+	if o.Limit > 0 && len(jobs.Items) > o.Limit {
+		jobs.Items = jobs.Items[:o.Limit]
+	}
+
+	w := kubectl.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	fmt.Fprintln(w, "JOB\tSTARTED\tDURATION\tSTATUS\tEXIT CODE")
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		started, duration := "-", "-"
+		if job.Status.StartTime != nil {
+			started = job.Status.StartTime.UTC().Format(time.RFC3339)
+			if job.Status.CompletionTime != nil {
+				duration = job.Status.CompletionTime.Sub(job.Status.StartTime.Time).String()
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", job.Name, started, duration, jobState(job), jobExitCode(o.KClient, o.Project, job))
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/apis/extensions"
+)
+
+func TestJobState(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []extensions.JobCondition
+		active     int
+		expected   string
+	}{
+		{name: "not started", expected: "Pending"},
+		{name: "running", active: 1, expected: "Running"},
+		{
+			name:       "complete",
+			conditions: []extensions.JobCondition{{Type: extensions.JobComplete, Status: kapi.ConditionTrue}},
+			expected:   "Succeeded",
+		},
+		{
+			name:       "failed",
+			conditions: []extensions.JobCondition{{Type: extensions.JobFailed, Status: kapi.ConditionTrue}},
+			expected:   "Failed",
+		},
+		{
+			name:       "condition not true",
+			conditions: []extensions.JobCondition{{Type: extensions.JobFailed, Status: kapi.ConditionFalse}},
+			active:     1,
+			expected:   "Running",
+		},
+	}
+
+	for _, test := range tests {
+		job := &extensions.Job{Status: extensions.JobStatus{Conditions: test.conditions, Active: test.active}}
+		if state := jobState(job); state != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, state)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kapierrors "k8s.io/kubernetes/pkg/api/errors"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	secureLong = `
+Require authentication and encryption between the pods of a cluster.
+
+A random shared secret is generated into a secret named <NAME>-auth. The
+master, the workers and the drivers launched by oshinko read it and set
+spark.authenticate, so only processes knowing the secret can join the cluster
+or submit applications to it. RPC and block transfer traffic is encrypted.
+
+The secret reaches spark through the _SPARK_AUTH_SECRET environment variable
+rather than spark.authenticate.secret, so it does not show on the java command
+line nor on the environment page of the web UI. Applications submitted from
+outside oshinko must set spark.authenticate and either variable to the value
+held by the secret.
+
+--rotate replaces the secret of a secured cluster and redeploys it. Running
+applications are lost.`
+
+	secureExample = `  # Secure the cluster 'mycluster'
+  %[1]s secure mycluster
+
+  # Replace the secret of the cluster 'mycluster'
+  %[1]s secure mycluster --rotate`
+)
+
+const (
+	authType      = "auth"
+	authSecretKey = "secret"
+
+	// authSecretEnv is read by spark before spark.authenticate.secret
+	authSecretEnv = "_SPARK_AUTH_SECRET"
+
+	// authRotatedAnnotation changes on the pod templates when the secret is
+	// replaced, so the pods are recreated with it
+	authRotatedAnnotation = "oshinko-auth-rotated"
+
+	authSecretBytes = 32
+)
+
+func authSecretName(clustername string) string {
+	return clustername + "-auth"
+}
+
+// randomToken returns n random bytes, hex encoded
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newAuthSecret generates a random shared secret for a cluster
+func newAuthSecret(clustername string) (*kapi.Secret, error) {
+	token, err := randomToken(authSecretBytes)
+	if err != nil {
+		return nil, err
+	}
+	return &kapi.Secret{
+		ObjectMeta: kapi.ObjectMeta{
+			Name:   authSecretName(clustername),
+			Labels: clusterLabels(authType, clustername),
+		},
+		Data: map[string][]byte{authSecretKey: []byte(token)},
+	}, nil
+}
+
+// isSecured reports whether a container reads the shared secret of a cluster
+func isSecured(c *kapi.Container) bool {
+	for _, e := range c.Env {
+		if e.Name == authSecretEnv && e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// secureContainer makes a spark container authenticate with the shared
+// secret of a cluster and encrypt its traffic. Authentication and encryption
+// are enabled in each of the java options variables given.
+func secureContainer(c *kapi.Container, clustername string, javaOptsEnvs ...string) {
+	removeEnv(c, authSecretEnv)
+	c.Env = append(c.Env, kapi.EnvVar{
+		Name: authSecretEnv,
+		ValueFrom: &kapi.EnvVarSource{
+			SecretKeyRef: &kapi.SecretKeySelector{
+				LocalObjectReference: kapi.LocalObjectReference{Name: authSecretName(clustername)},
+				Key:                  authSecretKey,
+			},
+		},
+	})
+
+	for _, opts := range javaOptsEnvs {
+		// the secret is never passed as an option, it would be visible
+		removeJavaArg(c, opts, "-Dspark.authenticate.secret=")
+		setJavaOption(c, opts, "spark.authenticate", "true")
+		setJavaOption(c, opts, "spark.network.crypto.enabled", "true")
+		setJavaOption(c, opts, "spark.authenticate.enableSaslEncryption", "true")
+		setJavaOption(c, opts, "spark.network.sasl.serverAlwaysEncrypt", "true")
+	}
+}
+
+// clusterSecured tells whether a cluster has a shared secret, the drivers
+// launched by oshinko authenticate with it then
+func clusterSecured(kClient *kclient.Client, namespace, clustername string) (bool, error) {
+	if _, err := kClient.Secrets(namespace).Get(authSecretName(clustername)); err != nil {
+		if kapierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+type SecureOptions struct {
+	ClusterCmdOptions
+
+	Rotate bool
+}
+
+// NewCmdSecure implements the oshinko secure command
+func NewCmdSecure(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &SecureOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "secure <NAME>",
+		Short:   "Enable authentication and encryption in a cluster",
+		Long:    secureLong,
+		Example: fmt.Sprintf(secureExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunSecure(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.Rotate, "rotate", false, "If true, replace the secret of a secured cluster and redeploy it")
+	return cmd
+}
+
+func (o *SecureOptions) RunSecure() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc != nil && sparkContainer(dc.Spec.Template) == nil {
+			return fmt.Errorf("deployment config %s has no containers", dc.Name)
+		}
+	}
+
+	secret, err := newAuthSecret(o.Name)
+	if err != nil {
+		return err
+	}
+	if o.Rotate {
+		if _, err := o.KClient.Secrets(o.Project).Get(secret.Name); kapierrors.IsNotFound(err) {
+			return fmt.Errorf("cluster %q is not secured, run secure without --rotate first", o.Name)
+		} else if err != nil {
+			return err
+		}
+		if _, err := o.KClient.Secrets(o.Project).Update(secret); err != nil {
+			return err
+		}
+	} else if _, err := o.KClient.Secrets(o.Project).Create(secret); err != nil && !kapierrors.IsAlreadyExists(err) {
+		// an existing secret is kept, the cluster may have been only partly secured
+		return err
+	}
+
+	rotated := time.Now().UTC().Format(time.RFC3339)
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil {
+			continue
+		}
+		c := sparkContainer(dc.Spec.Template)
+		if isSecured(c) && !o.Rotate {
+			continue
+		}
+		secureContainer(c, o.Name, daemonJavaOptsEnv, submitOptsEnv)
+		if o.Rotate {
+			if dc.Spec.Template.Annotations == nil {
+				dc.Spec.Template.Annotations = make(map[string]string)
+			}
+			dc.Spec.Template.Annotations[authRotatedAnnotation] = rotated
+		}
+		if _, err := updateAndDeploy(o.Client, o.Project, dc); err != nil {
+			return err
+		}
+	}
+
+	if o.Rotate {
+		fmt.Fprintf(o.Out, "secret of cluster %q replaced, redeploying\n", o.Name)
+	} else {
+		fmt.Fprintf(o.Out, "cluster %q secured with the secret %s\n", o.Name, secret.Name)
+	}
+	return nil
+}
+
+// describeSecurity shows whether the pods of a cluster authenticate each other
+func describeSecurity(w io.Writer, master, worker *deployapi.DeploymentConfig) {
+	secured := master != nil || worker != nil
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil {
+			continue
+		}
+		if c := sparkContainer(dc.Spec.Template); c == nil || !isSecured(c) {
+			secured = false
+		}
+	}
+	if secured {
+		fmt.Fprintln(w, "Authentication:\tenabled")
+	} else {
+		fmt.Fprintln(w, "Authentication:\tdisabled")
+	}
+}
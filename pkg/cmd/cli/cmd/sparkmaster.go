@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+)
+
+const masterPort = 7077
+const webPort = 8080
+const restPort = 6066
+
+// sparkMasterState is the part of the spark master's /json endpoint used by oshinko
+type sparkMasterState struct {
+	URL           string            `json:"url"`
+	Workers       []sparkWorkerInfo `json:"workers"`
+	Cores         int               `json:"cores"`
+	CoresUsed     int               `json:"coresused"`
+	Memory        int               `json:"memory"`
+	MemoryUsed    int               `json:"memoryused"`
+	ActiveApps    []sparkAppInfo    `json:"activeapps"`
+	CompletedApps []sparkAppInfo    `json:"completedapps"`
+	Status        string            `json:"status"`
+}
+
+type sparkWorkerInfo struct {
+	ID        string `json:"id"`
+	Host      string `json:"host"`
+	State     string `json:"state"`
+	Cores     int    `json:"cores"`
+	CoresUsed int    `json:"coresused"`
+}
+
+type sparkAppInfo struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Cores     int    `json:"cores"`
+	User      string `json:"user"`
+	StartTime int64  `json:"starttime"`
+	Duration  int64  `json:"duration"`
+	State     string `json:"state"`
+}
+
+// AliveWorkers counts the workers registered with the master which are alive
+func (s *sparkMasterState) AliveWorkers() int {
+	cnt := 0
+	for _, w := range s.Workers {
+		if w.State == "ALIVE" {
+			cnt++
+		}
+	}
+	return cnt
+}
+
+// runningMasterPod returns a running master pod of the cluster
+func runningMasterPod(kClient *kclient.Client, namespace, clustername string) (*kapi.Pod, error) {
+	pods, err := kClient.Pods(namespace).List(makeSelector(masterType, clustername))
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == kapi.PodRunning && pods.Items[i].DeletionTimestamp == nil {
+			return &pods.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no running master pod found for cluster %q", clustername)
+}
+
+// podProxyGet performs a GET against a port of a pod through the API server proxy
+func podProxyGet(kClient *kclient.Client, namespace, podname string, port int, path string) ([]byte, error) {
+	return kClient.Get().
+		Namespace(namespace).
+		Resource("pods").
+		Name(podname + ":" + strconv.Itoa(port)).
+		SubResource("proxy").
+		Suffix(path).
+		DoRaw()
+}
+
+// getMasterState reads the state of a cluster from its spark master
+func getMasterState(kClient *kclient.Client, namespace, clustername string) (*sparkMasterState, error) {
+	pod, err := runningMasterPod(kClient, namespace, clustername)
+	if err != nil {
+		return nil, err
+	}
+	body, err := podProxyGet(kClient, namespace, pod.Name, webPort, "json/")
+	if err != nil {
+		return nil, err
+	}
+	state := &sparkMasterState{}
+	if err := json.Unmarshal(body, state); err != nil {
+		return nil, fmt.Errorf("unable to read the state of the spark master: %v", err)
+	}
+	return state, nil
+}
+
+// webUIVersion matches the version shown in the header of the spark web UI
+var webUIVersion = regexp.MustCompile(`<span class="version"[^>]*>\s*([^<\s]+)\s*</span>`)
+
+// getSparkVersion asks the master for the version of spark it runs. The REST
+// submission server is asked for the status of a submission which does not
+// exist, the response carries the server version regardless. The server is
+// often disabled with spark.master.rest.enabled=false, the version shown by
+// the web UI is used then.
+func getSparkVersion(kClient *kclient.Client, namespace, clustername string) (string, error) {
+	pod, err := runningMasterPod(kClient, namespace, clustername)
+	if err != nil {
+		return "", err
+	}
+	if body, err := podProxyGet(kClient, namespace, pod.Name, restPort, "v1/submissions/status/oshinko"); err == nil {
+		resp := struct {
+			ServerSparkVersion string `json:"serverSparkVersion"`
+		}{}
+		if err := json.Unmarshal(body, &resp); err == nil && resp.ServerSparkVersion != "" {
+			return resp.ServerSparkVersion, nil
+		}
+	}
+	body, err := podProxyGet(kClient, namespace, pod.Name, webPort, "")
+	if err == nil {
+		if match := webUIVersion.FindSubmatch(body); match != nil {
+			return string(match[1]), nil
+		}
+	}
+	return "", fmt.Errorf("unable to determine the spark version of cluster %q", clustername)
+}
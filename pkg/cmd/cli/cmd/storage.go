@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/resource"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	storageLong = `
+Attach storage to the pods of a cluster.
+
+--scratch-size gives the workers scratch space for shuffle and spill files,
+used through SPARK_LOCAL_DIRS. Each worker gets an emptyDir volume on its
+node. emptyDir volumes cannot be limited in size, the size is only recorded
+and shown by describe, and the command says so. The workers of a cluster
+share the pod template of one deployment config, which cannot give each
+worker a claim of its own, so --storage-class is refused.
+
+--event-log-pvc mounts an existing claim on the master and the workers and
+sets spark.eventLog.dir to it for applications submitted from the cluster, so
+finished applications can be inspected later.`
+
+	storageExample = `  # Give each worker 50Gi of scratch space and keep event logs on the claim 'spark-events'
+  %[1]s storage mycluster --scratch-size 50Gi --event-log-pvc spark-events`
+)
+
+const (
+	scratchVolumeName     = "spark-scratch"
+	scratchMountPath      = "/spark-scratch"
+	eventLogVolumeName    = "spark-event-log"
+	eventLogMountPath     = "/spark-events"
+	localDirsEnv          = "SPARK_LOCAL_DIRS"
+	submitOptsEnv         = "SPARK_SUBMIT_OPTS"
+	scratchSizeAnnotation = "oshinko-scratch-size"
+)
+
+// setVolume adds a volume to a pod template and mounts it in the spark
+// container, replacing a volume of the same name
+func setVolume(template *kapi.PodTemplateSpec, volume kapi.Volume, mountPath string) {
+	removeVolume(template, volume.Name)
+	template.Spec.Volumes = append(template.Spec.Volumes, volume)
+	c := sparkContainer(template)
+	c.VolumeMounts = append(c.VolumeMounts, kapi.VolumeMount{Name: volume.Name, MountPath: mountPath})
+}
+
+func removeVolume(template *kapi.PodTemplateSpec, name string) {
+	volumes := []kapi.Volume{}
+	for _, v := range template.Spec.Volumes {
+		if v.Name != name {
+			volumes = append(volumes, v)
+		}
+	}
+	template.Spec.Volumes = volumes
+	c := sparkContainer(template)
+	mounts := []kapi.VolumeMount{}
+	for _, m := range c.VolumeMounts {
+		if m.Name != name {
+			mounts = append(mounts, m)
+		}
+	}
+	c.VolumeMounts = mounts
+}
+
+// setJavaOption sets a -Dkey=value system property in an environment
+// variable holding java options, keeping the other options
+func setJavaOption(c *kapi.Container, env, key, value string) {
+	setJavaArg(c, env, "-D"+key+"=", fmt.Sprintf("-D%s=%s", key, value))
+}
+
+// setJavaArg replaces the java options starting with prefix in an environment
+// variable holding java options by arg
+func setJavaArg(c *kapi.Container, env, prefix, arg string) {
+	options := []string{}
+	for _, o := range strings.Fields(envValue(c, env)) {
+		if !strings.HasPrefix(o, prefix) {
+			options = append(options, o)
+		}
+	}
+	options = append(options, arg)
+	setEnv(c, env, strings.Join(options, " "))
+}
+
+// removeJavaArg removes the java options starting with prefix from an
+// environment variable holding java options, and the variable once empty
+func removeJavaArg(c *kapi.Container, env, prefix string) {
+	options := []string{}
+	for _, o := range strings.Fields(envValue(c, env)) {
+		if !strings.HasPrefix(o, prefix) {
+			options = append(options, o)
+		}
+	}
+	if len(options) == 0 {
+		removeEnv(c, env)
+		return
+	}
+	setEnv(c, env, strings.Join(options, " "))
+}
+
+// javaOptions returns the -Dkey=value system properties of an environment
+// variable holding java options by key
+func javaOptions(c *kapi.Container, env string) map[string]string {
+	properties := map[string]string{}
+	for _, o := range strings.Fields(envValue(c, env)) {
+		if !strings.HasPrefix(o, "-D") {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimPrefix(o, "-D"), "=", 2)
+		if len(kv) == 2 {
+			properties[kv[0]] = kv[1]
+		}
+	}
+	return properties
+}
+
+type StorageOptions struct {
+	ClusterCmdOptions
+
+	ScratchSize  string
+	StorageClass string
+	EventLogPVC  string
+}
+
+// NewCmdStorage implements the oshinko storage command
+func NewCmdStorage(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &StorageOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "storage <NAME>",
+		Short:   "Attach scratch space and event log storage to a cluster",
+		Long:    storageLong,
+		Example: fmt.Sprintf(storageExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunStorage(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.ScratchSize, "scratch-size", "", "The scratch space of each worker, e.g. 50Gi")
+	cmd.Flags().StringVar(&options.StorageClass, "storage-class", "", "Not supported, the workers of a cluster cannot have a claim each")
+	cmd.Flags().StringVar(&options.EventLogPVC, "event-log-pvc", "", "An existing claim to keep spark event logs on")
+	return cmd
+}
+
+func (o *StorageOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if err := o.ClusterCmdOptions.Complete(f, args, out); err != nil {
+		return err
+	}
+	if o.ScratchSize == "" && o.EventLogPVC == "" {
+		return fmt.Errorf("at least one of --scratch-size or --event-log-pvc must be specified")
+	}
+	if o.StorageClass != "" {
+		return fmt.Errorf("--storage-class is not supported: the workers share the pod template of one deployment config, which cannot give each worker its own claim; omit it to give each worker an emptyDir volume")
+	}
+	return nil
+}
+
+func (o *StorageOptions) RunStorage() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc != nil && sparkContainer(dc.Spec.Template) == nil {
+			return fmt.Errorf("deployment config %s has no containers", dc.Name)
+		}
+	}
+	if o.EventLogPVC != "" {
+		if _, err := o.KClient.PersistentVolumeClaims(o.Project).Get(o.EventLogPVC); err != nil {
+			return err
+		}
+	}
+
+	if o.ScratchSize != "" {
+		if worker == nil {
+			return fmt.Errorf("cluster %q has no worker deployment config", o.Name)
+		}
+		size, err := resource.ParseQuantity(o.ScratchSize)
+		if err != nil {
+			return fmt.Errorf("invalid scratch size %q: %v", o.ScratchSize, err)
+		}
+		volume := kapi.Volume{
+			Name:         scratchVolumeName,
+			VolumeSource: kapi.VolumeSource{EmptyDir: &kapi.EmptyDirVolumeSource{}},
+		}
+		setVolume(worker.Spec.Template, volume, scratchMountPath)
+		setEnv(sparkContainer(worker.Spec.Template), localDirsEnv, scratchMountPath)
+		if worker.Annotations == nil {
+			worker.Annotations = make(map[string]string)
+		}
+		worker.Annotations[scratchSizeAnnotation] = size.String()
+		fmt.Fprintf(o.Out, "warning: emptyDir volumes cannot be limited in size, the %s of scratch space per worker is not enforced\n", size.String())
+	}
+
+	if o.EventLogPVC != "" {
+		volume := kapi.Volume{
+			Name: eventLogVolumeName,
+			VolumeSource: kapi.VolumeSource{
+				PersistentVolumeClaim: &kapi.PersistentVolumeClaimVolumeSource{ClaimName: o.EventLogPVC},
+			},
+		}
+		for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+			if dc == nil {
+				continue
+			}
+			setVolume(dc.Spec.Template, volume, eventLogMountPath)
+			c := sparkContainer(dc.Spec.Template)
+			setJavaOption(c, submitOptsEnv, "spark.eventLog.enabled", "true")
+			setJavaOption(c, submitOptsEnv, "spark.eventLog.dir", "file://"+eventLogMountPath)
+		}
+	}
+
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil {
+			continue
+		}
+		if _, err := updateAndDeploy(o.Client, o.Project, dc); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(o.Out, "storage of cluster %q updated\n", o.Name)
+	return nil
+}
+
+// describeVolume summarizes the source of a volume
+func describeVolume(v kapi.Volume) string {
+	switch {
+	case v.EmptyDir != nil:
+		return "emptyDir"
+	case v.PersistentVolumeClaim != nil:
+		return "claim " + v.PersistentVolumeClaim.ClaimName
+	case v.Secret != nil:
+		return "secret " + v.Secret.SecretName
+	case v.ConfigMap != nil:
+		return "config map " + v.ConfigMap.Name
+	case v.HostPath != nil:
+		return "host path " + v.HostPath.Path
+	}
+	return "other"
+}
+
+// describeStorage lists the volumes mounted in the spark containers of a cluster
+func describeStorage(w io.Writer, master, worker *deployapi.DeploymentConfig) {
+	fmt.Fprintln(w, "Storage:")
+	found := false
+	for _, role := range []struct {
+		title string
+		dc    *deployapi.DeploymentConfig
+	}{
+		{"master", master},
+		{"workers", worker},
+	} {
+		if role.dc == nil {
+			continue
+		}
+		c := sparkContainer(role.dc.Spec.Template)
+		if c == nil {
+			continue
+		}
+		for _, m := range c.VolumeMounts {
+			for _, v := range role.dc.Spec.Template.Spec.Volumes {
+				if v.Name != m.Name {
+					continue
+				}
+				source := describeVolume(v)
+				if size := role.dc.Annotations[scratchSizeAnnotation]; v.Name == scratchVolumeName && size != "" {
+					source += fmt.Sprintf(" (%s per worker, not enforced)", size)
+				}
+				fmt.Fprintf(w, "  %s\t%s on %s at %s\n", v.Name, source, role.title, m.MountPath)
+				found = true
+			}
+		}
+	}
+	if !found {
+		fmt.Fprintln(w, "  <none>")
+	}
+}
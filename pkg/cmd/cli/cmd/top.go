@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	hmetrics "github.com/hawkular/hawkular-client-go/metrics"
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/client/restclient"
+	kclient "k8s.io/kubernetes/pkg/client/unversioned"
+	"k8s.io/kubernetes/pkg/kubectl"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/labels"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+)
+
+const (
+	topLong = `
+Show the cpu and memory used by the pods of a cluster.
+
+Usage is read from the OpenShift metrics stack, averaged over the last %v.
+Hawkular is reached through its public route, the metricsPublicURL the master
+gives the web console, unless --metrics-url is given. A certificate of the
+route which is not signed by a system certificate authority is only accepted
+with --insecure-skip-tls-verify. The share of the limit of each pod is shown
+where limits are set, so oversized and starved clusters stand out.`
+
+	topExample = `  # Show the usage of the pods of the cluster 'mycluster', by memory
+  %[1]s top mycluster --sort-by memory
+
+  # Show the usage of every cluster in the project
+  %[1]s top clusters`
+
+	topClustersLong = `
+Show the cpu and memory used by each cluster in the project, sorted by usage.`
+)
+
+const (
+	// consoleConfigPath serves the web console configuration, which carries
+	// the public URL of the metrics stack
+	consoleConfigPath = "/console/config.js"
+
+	cpuUsageDescriptor    = "cpu/usage_rate"
+	memoryUsageDescriptor = "memory/usage"
+	usageWindow           = 5 * time.Minute
+)
+
+// podUsage is the cpu, in millicores, and the memory, in bytes, used by a pod
+type podUsage struct {
+	Name        string
+	Cluster     string
+	Role        string
+	Pods        int
+	CPU         int64
+	Memory      int64
+	CPULimit    int64
+	MemoryLimit int64
+}
+
+func (u *podUsage) add(o podUsage) {
+	u.Pods += o.Pods
+	u.CPU += o.CPU
+	u.Memory += o.Memory
+	u.CPULimit += o.CPULimit
+	u.MemoryLimit += o.MemoryLimit
+}
+
+// byUsage sorts by decreasing cpu or memory usage
+type byUsage struct {
+	items  []podUsage
+	memory bool
+}
+
+func (s byUsage) Len() int      { return len(s.items) }
+func (s byUsage) Swap(i, j int) { s.items[i], s.items[j] = s.items[j], s.items[i] }
+func (s byUsage) Less(i, j int) bool {
+	if s.memory {
+		return s.items[i].Memory > s.items[j].Memory
+	}
+	return s.items[i].CPU > s.items[j].CPU
+}
+
+var consoleMetricsURL = regexp.MustCompile(`metricsURL:\s*"([^"]*)"`)
+
+// parseConsoleMetricsURL returns the metrics URL of a web console
+// configuration, empty when the master has none
+func parseConsoleMetricsURL(config []byte) string {
+	match := consoleMetricsURL.FindSubmatch(config)
+	if match == nil {
+		return ""
+	}
+	return string(match[1])
+}
+
+// metricsPublicURL asks the master for the public URL of the metrics stack.
+// The API server proxy to the hawkular-metrics service is not an option,
+// proxying to services of openshift-infra is denied to ordinary users.
+func metricsPublicURL(kClient *kclient.Client) (string, error) {
+	body, err := kClient.Get().AbsPath(consoleConfigPath).DoRaw()
+	if err != nil {
+		return "", fmt.Errorf("unable to read the metrics URL from the web console configuration, use --metrics-url: %v", err)
+	}
+	url := parseConsoleMetricsURL(body)
+	if url == "" {
+		return "", fmt.Errorf("the master has no metricsPublicURL configured, is the metrics stack deployed? Use --metrics-url to give the URL of Hawkular metrics")
+	}
+	return url, nil
+}
+
+// newMetricsClient creates a Hawkular client for the metrics of a project,
+// authenticated like the OpenShift client
+func newMetricsClient(config *restclient.Config, url, namespace string) (*hmetrics.Client, error) {
+	tlsConfig, err := restclient.TLSConfigFor(config)
+	if err != nil {
+		return nil, err
+	}
+	// a route is not signed by the certificate authority of the API server
+	// and has no use for its client certificates
+	if tlsConfig != nil && !strings.HasPrefix(url, strings.TrimRight(config.Host, "/")+"/") {
+		tlsConfig.RootCAs = nil
+		tlsConfig.Certificates = nil
+	}
+	return hmetrics.NewHawkularClient(hmetrics.Parameters{
+		Tenant:    namespace,
+		Url:       url,
+		TLSConfig: tlsConfig,
+		Token:     config.BearerToken,
+	})
+}
+
+// averageUsage averages a metric of a pod over the usage window
+func averageUsage(mClient *hmetrics.Client, pod, descriptor string) (int64, error) {
+	tags := map[string]string{"descriptor_name": descriptor, "pod_name": pod, "type": "pod"}
+	buckets, err := mClient.ReadBuckets(hmetrics.Gauge, hmetrics.Filters(
+		hmetrics.TagsFilter(tags),
+		hmetrics.BucketsFilter(1),
+		hmetrics.StartTimeFilter(time.Now().Add(-usageWindow)),
+	))
+	if err != nil {
+		return 0, err
+	}
+	if len(buckets) == 0 || buckets[0].Empty {
+		return 0, nil
+	}
+	return int64(buckets[0].Avg), nil
+}
+
+// podsUsage reads the usage of running pods from Hawkular
+func podsUsage(mClient *hmetrics.Client, pods []kapi.Pod) ([]podUsage, error) {
+	usage := []podUsage{}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase != kapi.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		u := podUsage{
+			Name:    pod.Name,
+			Cluster: pod.Labels[clusterLabel],
+			Role:    pod.Labels[typeLabel],
+			Pods:    1,
+		}
+		var err error
+		if u.CPU, err = averageUsage(mClient, pod.Name, cpuUsageDescriptor); err != nil {
+			return nil, fmt.Errorf("unable to read metrics, is the metrics stack deployed? %v", err)
+		}
+		if u.Memory, err = averageUsage(mClient, pod.Name, memoryUsageDescriptor); err != nil {
+			return nil, fmt.Errorf("unable to read metrics, is the metrics stack deployed? %v", err)
+		}
+		for _, c := range pod.Spec.Containers {
+			if q, ok := c.Resources.Limits[kapi.ResourceCPU]; ok {
+				u.CPULimit += q.MilliValue()
+			}
+			if q, ok := c.Resources.Limits[kapi.ResourceMemory]; ok {
+				u.MemoryLimit += q.Value()
+			}
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+// shareOfLimit prints usage as a percentage of a limit
+func shareOfLimit(used, limit int64) string {
+	if limit == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", used*100/limit)
+}
+
+func printUsage(w io.Writer, name string, u podUsage) {
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name,
+		formatAmount(kapi.ResourceCPU, u.CPU), shareOfLimit(u.CPU, u.CPULimit),
+		resource.NewQuantity(u.Memory, resource.BinarySI).String(), shareOfLimit(u.Memory, u.MemoryLimit))
+}
+
+type TopOptions struct {
+	ClusterCmdOptions
+
+	MetricsURL string
+	SortBy     string
+
+	metricsClient *hmetrics.Client
+}
+
+// completeMetrics validates the sort order and creates the metrics client
+func (o *TopOptions) completeMetrics(f *clientcmd.Factory) error {
+	if o.SortBy != "cpu" && o.SortBy != "memory" {
+		return fmt.Errorf("--sort-by must be cpu or memory")
+	}
+	config, err := f.OpenShiftClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	url := o.MetricsURL
+	if url == "" {
+		if url, err = metricsPublicURL(o.KClient); err != nil {
+			return err
+		}
+	}
+	o.metricsClient, err = newMetricsClient(config, url, o.Project)
+	return err
+}
+
+func (o *TopOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.MetricsURL, "metrics-url", "", "The URL of Hawkular metrics, defaults to the metricsPublicURL of the master")
+	cmd.Flags().StringVar(&o.SortBy, "sort-by", "cpu", "Sort by cpu or memory usage")
+}
+
+// NewCmdTop implements the oshinko top command
+func NewCmdTop(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &TopOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "top <NAME>",
+		Short:   "Show the cpu and memory used by a cluster",
+		Long:    fmt.Sprintf(topLong, usageWindow),
+		Example: fmt.Sprintf(topExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := options.Complete(f, args, out)
+			if err == nil {
+				err = options.completeMetrics(f)
+			}
+			if err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunTop(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	options.addFlags(cmd)
+	cmd.AddCommand(NewCmdTopClusters(fullName, f, out))
+	return cmd
+}
+
+// NewCmdTopClusters implements the oshinko top clusters command
+func NewCmdTopClusters(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &TopOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "clusters",
+		Short: "Show the cpu and memory used by each cluster",
+		Long:  topClustersLong,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := options.completeClients(f, out)
+			if err == nil && len(args) > 0 {
+				err = fmt.Errorf("no arguments should be passed")
+			}
+			if err == nil {
+				err = options.completeMetrics(f)
+			}
+			if err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunTopClusters(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	options.addFlags(cmd)
+	return cmd
+}
+
+func (o *TopOptions) RunTop() error {
+	if _, _, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name); err != nil {
+		return err
+	}
+	pods, err := o.KClient.Pods(o.Project).List(makeSelector("", o.Name))
+	if err != nil {
+		return err
+	}
+	usage, err := podsUsage(o.metricsClient, pods.Items)
+	if err != nil {
+		return err
+	}
+	if len(usage) == 0 {
+		fmt.Fprintf(o.Out, "cluster %q has no running pods\n", o.Name)
+		return nil
+	}
+	sort.Sort(byUsage{usage, o.SortBy == "memory"})
+
+	w := kubectl.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	fmt.Fprintln(w, "POD\tCPU\tCPU/LIMIT\tMEMORY\tMEMORY/LIMIT")
+	total := podUsage{}
+	for _, u := range usage {
+		printUsage(w, fmt.Sprintf("%s (%s)", u.Name, u.Role), u)
+		total.add(u)
+	}
+	printUsage(w, "TOTAL", total)
+	return nil
+}
+
+func (o *TopOptions) RunTopClusters() error {
+	// only the pods of clusters, the others would cost metrics requests for nothing
+	selector, err := labels.Parse(clusterLabel)
+	if err != nil {
+		return err
+	}
+	pods, err := o.KClient.Pods(o.Project).List(kapi.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return err
+	}
+	usage, err := podsUsage(o.metricsClient, pods.Items)
+	if err != nil {
+		return err
+	}
+
+	perCluster := map[string]*podUsage{}
+	for _, u := range usage {
+		if u.Cluster == "" {
+			continue
+		}
+		if _, ok := perCluster[u.Cluster]; !ok {
+			perCluster[u.Cluster] = &podUsage{Name: u.Cluster}
+		}
+		perCluster[u.Cluster].add(u)
+	}
+	if len(perCluster) == 0 {
+		fmt.Fprintln(o.Out, "There are no running clusters.")
+		return nil
+	}
+	clusters := []podUsage{}
+	for _, u := range perCluster {
+		clusters = append(clusters, *u)
+	}
+	sort.Sort(byUsage{clusters, o.SortBy == "memory"})
+
+	w := kubectl.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	fmt.Fprintln(w, "CLUSTER\tPODS\tCPU\tCPU/LIMIT\tMEMORY\tMEMORY/LIMIT")
+	for _, u := range clusters {
+		printUsage(w, fmt.Sprintf("%s\t%d", u.Name, u.Pods), u)
+	}
+	return nil
+}
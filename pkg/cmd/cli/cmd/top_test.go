@@ -0,0 +1,35 @@
+package cmd
+
+import "testing"
+
+func TestParseConsoleMetricsURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{
+			name: "configured",
+			config: `window.OPENSHIFT_CONFIG = {
+  loggingURL: "",
+  metricsURL: "https://hawkular-metrics.example.com/hawkular/metrics",
+  limitRequestOverrides: null
+};`,
+			expected: "https://hawkular-metrics.example.com/hawkular/metrics",
+		},
+		{
+			name:   "not configured",
+			config: `window.OPENSHIFT_CONFIG = { loggingURL: "", metricsURL: "" };`,
+		},
+		{
+			name:   "older master",
+			config: `window.OPENSHIFT_CONFIG = { loggingURL: "" };`,
+		},
+	}
+
+	for _, test := range tests {
+		if got := parseConsoleMetricsURL([]byte(test.config)); got != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, got)
+		}
+	}
+}
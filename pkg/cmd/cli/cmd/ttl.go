@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+
+	ocutil "github.com/openshift/origin/pkg/cmd/util"
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	ttlLong = `
+Manage the time to live of clusters.
+
+A cluster with an expiry is deleted by the gc command once the expiry has
+passed.`
+
+	ttlSetLong = `
+Set the time to live of a cluster.
+
+The expiry is computed from the current time and recorded on the deployment
+configs of the cluster.`
+
+	ttlSetExample = `  # Let the cluster 'mycluster' live for another 8 hours
+  %[1]s ttl set mycluster 8h`
+)
+
+// expiresAtAnnotation holds the time after which a cluster may be garbage collected
+const expiresAtAnnotation = "oshinko-expires-at"
+
+// clusterExpiry returns the earliest expiry recorded on the deployment
+// configs of a cluster, or the zero time if it has none
+func clusterExpiry(dcs ...*deployapi.DeploymentConfig) time.Time {
+	var expiry time.Time
+	for _, dc := range dcs {
+		if dc == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, dc.Annotations[expiresAtAnnotation])
+		if err != nil {
+			continue
+		}
+		if expiry.IsZero() || t.Before(expiry) {
+			expiry = t
+		}
+	}
+	return expiry
+}
+
+// NewCmdTTL implements the oshinko ttl command
+func NewCmdTTL(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ttl",
+		Short: "Manage the time to live of clusters",
+		Long:  ttlLong,
+		Run:   ocutil.DefaultSubCommandRun(out),
+	}
+	cmd.AddCommand(NewCmdTTLSet(fullName, f, out))
+	return cmd
+}
+
+type TTLSetOptions struct {
+	ClusterCmdOptions
+
+	TTL time.Duration
+}
+
+// NewCmdTTLSet implements the oshinko ttl set command
+func NewCmdTTLSet(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &TTLSetOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "set <NAME> <DURATION>",
+		Short:   "Set the time to live of a cluster",
+		Long:    ttlSetLong,
+		Example: fmt.Sprintf(ttlSetExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunTTLSet(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+	return cmd
+}
+
+func (o *TTLSetOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("a cluster name and a duration must be specified")
+	}
+	var err error
+	o.TTL, err = time.ParseDuration(args[1])
+	if err != nil {
+		return err
+	}
+	if o.TTL <= 0 {
+		return fmt.Errorf("the duration must be positive")
+	}
+	return o.ClusterCmdOptions.Complete(f, args[:1], out)
+}
+
+func (o *TTLSetOptions) RunTTLSet() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	expiry := time.Now().UTC().Add(o.TTL).Format(time.RFC3339)
+	for _, dc := range []*deployapi.DeploymentConfig{master, worker} {
+		if dc == nil {
+			continue
+		}
+		if dc.Annotations == nil {
+			dc.Annotations = make(map[string]string)
+		}
+		dc.Annotations[expiresAtAnnotation] = expiry
+		if _, err := o.Client.DeploymentConfigs(o.Project).Update(dc); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(o.Out, "cluster %q expires at %s\n", o.Name, expiry)
+	return nil
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+	kcmdutil "k8s.io/kubernetes/pkg/kubectl/cmd/util"
+	"k8s.io/kubernetes/pkg/util/intstr"
+	"k8s.io/kubernetes/pkg/util/sets"
+	"k8s.io/kubernetes/pkg/util/wait"
+
+	"github.com/openshift/origin/pkg/cmd/util/clientcmd"
+	deployapi "github.com/openshift/origin/pkg/deploy/api"
+)
+
+const (
+	upgradeLong = `
+Upgrade the spark image of a cluster.
+
+The master is updated first. Once it is healthy again the workers are rolled
+in batches of at most --max-unavailable pods. The upgrade succeeds when every
+new worker pod has registered with the master and the master reports a spark
+version other than before the upgrade, or the version given with
+--spark-version. Otherwise the deployment configs of the master and the
+workers are rolled back to their previous state. Pass --spark-version to
+change the image without changing the spark version.
+
+The version is read from the REST submission server of the master, or from its
+web UI when the server is disabled. The workers are rolled with a rolling
+strategy, their own strategy is restored once the upgrade is done. Python
+dependencies added by python-deps are installed again with the new image.`
+
+	upgradeExample = `  # Upgrade the cluster 'mycluster' to a new image, two workers at a time
+  %[1]s upgrade mycluster --image radanalyticsio/openshift-spark:2.0 --max-unavailable 2
+
+  # Upgrade and require the master to report spark 2.0.0
+  %[1]s upgrade mycluster --image radanalyticsio/openshift-spark:2.0 --spark-version 2.0.0`
+)
+
+type UpgradeOptions struct {
+	ClusterCmdOptions
+
+	Image          string
+	MaxUnavailable int
+	SparkVersion   string
+	Timeout        time.Duration
+}
+
+// NewCmdUpgrade implements the oshinko upgrade command
+func NewCmdUpgrade(fullName string, f *clientcmd.Factory, out io.Writer) *cobra.Command {
+	options := &UpgradeOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "upgrade <NAME> --image <IMAGE>",
+		Short:   "Roll a cluster to a new spark image",
+		Long:    upgradeLong,
+		Example: fmt.Sprintf(upgradeExample, fullName),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := options.Complete(f, args, out); err != nil {
+				kcmdutil.CheckErr(kcmdutil.UsageError(cmd, err.Error()))
+			}
+
+			if err := options.RunUpgrade(); err != nil {
+				kcmdutil.CheckErr(err)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&options.Image, "image", "", "The spark image to run on the master and the workers")
+	cmd.Flags().IntVar(&options.MaxUnavailable, "max-unavailable", 1, "The number of workers which may be unavailable at once while rolling")
+	cmd.Flags().StringVar(&options.SparkVersion, "spark-version", "", "If set, the spark version the master must report after the upgrade")
+	cmd.Flags().DurationVar(&options.Timeout, "timeout", 5*time.Minute, "How long to wait for each step before rolling back")
+	return cmd
+}
+
+func (o *UpgradeOptions) Complete(f *clientcmd.Factory, args []string, out io.Writer) error {
+	if err := o.ClusterCmdOptions.Complete(f, args, out); err != nil {
+		return err
+	}
+	if o.Image == "" {
+		return fmt.Errorf("--image must be specified")
+	}
+	if o.MaxUnavailable < 1 {
+		return fmt.Errorf("--max-unavailable must be at least 1")
+	}
+	return nil
+}
+
+// rollbackSpec restores the spec of a deployment config saved before an upgrade
+func (o *UpgradeOptions) rollbackSpec(name string, spec deployapi.DeploymentConfigSpec) error {
+	dc, err := o.Client.DeploymentConfigs(o.Project).Get(name)
+	if err != nil {
+		return err
+	}
+	dc.Spec = spec
+	_, err = updateAndDeploy(o.Client, o.Project, dc)
+	return err
+}
+
+// restoreStrategy puts back the deployment strategy of a deployment config,
+// which does not start a new deployment
+func (o *UpgradeOptions) restoreStrategy(name string, strategy deployapi.DeploymentStrategy) error {
+	dc, err := o.Client.DeploymentConfigs(o.Project).Get(name)
+	if err != nil {
+		return err
+	}
+	dc.Spec.Strategy = strategy
+	_, err = o.Client.DeploymentConfigs(o.Project).Update(dc)
+	return err
+}
+
+// registeredWorkers counts the worker pods running the given image which are
+// alive in the master. Workers register with their pod IP or host name. A
+// worker which went away stays alive in the master until it times out, so the
+// count of alive workers alone says nothing about the new pods.
+func registeredWorkers(state *sparkMasterState, pods []kapi.Pod, image string) int {
+	alive := sets.NewString()
+	for _, w := range state.Workers {
+		if w.State == "ALIVE" {
+			alive.Insert(w.Host)
+		}
+	}
+	count := 0
+	for _, pod := range pods {
+		if len(pod.Spec.Containers) == 0 || pod.Spec.Containers[0].Image != image {
+			continue
+		}
+		if alive.Has(pod.Status.PodIP) || alive.Has(pod.Name) {
+			count++
+		}
+	}
+	return count
+}
+
+func (o *UpgradeOptions) rollback(cause error, master, worker *deployapi.DeploymentConfig) error {
+	fmt.Fprintf(o.Out, "upgrade failed, rolling back: %v\n", cause)
+	if err := o.rollbackSpec(master.Name, master.Spec); err != nil {
+		return fmt.Errorf("%v; rollback of %s failed: %v", cause, master.Name, err)
+	}
+	if err := o.rollbackSpec(worker.Name, worker.Spec); err != nil {
+		return fmt.Errorf("%v; rollback of %s failed: %v", cause, worker.Name, err)
+	}
+	return cause
+}
+
+func (o *UpgradeOptions) RunUpgrade() error {
+	master, worker, err := clusterDeploymentConfigs(o.Client, o.Project, o.Name)
+	if err != nil {
+		return err
+	}
+	if master == nil || worker == nil {
+		return fmt.Errorf("cluster %q is missing its master or worker deployment config", o.Name)
+	}
+
+	// keep the original configs around so they can be restored on failure
+	saved, err := kapi.Scheme.DeepCopy([]*deployapi.DeploymentConfig{master, worker})
+	if err != nil {
+		return err
+	}
+	original := saved.([]*deployapi.DeploymentConfig)
+
+	oldVersion, _ := getSparkVersion(o.KClient, o.Project, o.Name)
+
+	c := sparkContainer(master.Spec.Template)
+	if c == nil {
+		return fmt.Errorf("deployment config %s has no containers", master.Name)
+	}
+	c.Image = o.Image
+	if err := setPythonDepsImage(master.Spec.Template, o.Image); err != nil {
+		return err
+	}
+	if _, err := updateAndDeploy(o.Client, o.Project, master); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "master updated, waiting for it to be ready\n")
+	if err := waitForClusterPods(o.KClient, o.Project, o.Name, masterType, o.Image, master.Spec.Replicas, o.Timeout); err != nil {
+		return o.rollback(err, original[0], original[1])
+	}
+
+	c = sparkContainer(worker.Spec.Template)
+	if c == nil {
+		return o.rollback(fmt.Errorf("deployment config %s has no containers", worker.Name), original[0], original[1])
+	}
+	c.Image = o.Image
+	if err := setPythonDepsImage(worker.Spec.Template, o.Image); err != nil {
+		return o.rollback(err, original[0], original[1])
+	}
+	worker.Spec.Strategy.Type = deployapi.DeploymentStrategyTypeRolling
+	if worker.Spec.Strategy.RollingParams == nil {
+		worker.Spec.Strategy.RollingParams = &deployapi.RollingDeploymentStrategyParams{}
+	}
+	worker.Spec.Strategy.RollingParams.MaxUnavailable = intstr.FromInt(o.MaxUnavailable)
+	worker.Spec.Strategy.RollingParams.MaxSurge = intstr.FromInt(0)
+	if _, err := updateAndDeploy(o.Client, o.Project, worker); err != nil {
+		return o.rollback(err, original[0], original[1])
+	}
+	fmt.Fprintf(o.Out, "rolling %d worker(s), %d at a time\n", worker.Spec.Replicas, o.MaxUnavailable)
+	if err := waitForClusterPods(o.KClient, o.Project, o.Name, workerType, o.Image, worker.Spec.Replicas, o.Timeout); err != nil {
+		return o.rollback(err, original[0], original[1])
+	}
+
+	err = wait.PollImmediate(pollInterval, o.Timeout, func() (bool, error) {
+		state, err := getMasterState(o.KClient, o.Project, o.Name)
+		if err != nil {
+			// the master may still be starting its web ui
+			return false, nil
+		}
+		pods, err := runningPods(o.KClient, o.Project, o.Name, workerType)
+		if err != nil {
+			return false, nil
+		}
+		return registeredWorkers(state, pods, o.Image) >= worker.Spec.Replicas, nil
+	})
+	if err != nil {
+		return o.rollback(fmt.Errorf("workers did not register with the master within %v", o.Timeout), original[0], original[1])
+	}
+
+	newVersion, err := getSparkVersion(o.KClient, o.Project, o.Name)
+	switch {
+	case err != nil:
+		return o.rollback(err, original[0], original[1])
+	case o.SparkVersion != "" && newVersion != o.SparkVersion:
+		return o.rollback(fmt.Errorf("master reports spark %s, expected %s", newVersion, o.SparkVersion), original[0], original[1])
+	case o.SparkVersion == "" && newVersion == oldVersion:
+		return o.rollback(fmt.Errorf("master still reports spark %s, pass --spark-version %s to keep the version", newVersion, newVersion), original[0], original[1])
+	}
+
+	// the rolling strategy was only needed for the upgrade
+	if err := o.restoreStrategy(worker.Name, original[1].Spec.Strategy); err != nil {
+		return fmt.Errorf("cluster %q upgraded, but restoring the strategy of %s failed: %v", o.Name, worker.Name, err)
+	}
+
+	switch {
+	case oldVersion != "" && oldVersion != newVersion:
+		fmt.Fprintf(o.Out, "cluster %q upgraded from spark %s to %s\n", o.Name, oldVersion, newVersion)
+	default:
+		fmt.Fprintf(o.Out, "cluster %q upgraded, running spark %s\n", o.Name, newVersion)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"testing"
+
+	kapi "k8s.io/kubernetes/pkg/api"
+)
+
+func workerPod(name, ip, image string) kapi.Pod {
+	return kapi.Pod{
+		ObjectMeta: kapi.ObjectMeta{Name: name},
+		Spec:       kapi.PodSpec{Containers: []kapi.Container{{Name: "spark", Image: image}}},
+		Status:     kapi.PodStatus{PodIP: ip},
+	}
+}
+
+func TestRegisteredWorkers(t *testing.T) {
+	state := &sparkMasterState{Workers: []sparkWorkerInfo{
+		{Host: "10.1.0.1", State: "ALIVE"},
+		{Host: "10.1.0.2", State: "ALIVE"},
+		{Host: "spark-w-2-abcde", State: "ALIVE"},
+		{Host: "10.1.0.4", State: "DEAD"},
+	}}
+
+	tests := []struct {
+		name     string
+		pods     []kapi.Pod
+		expected int
+	}{
+		{name: "no pods", expected: 0},
+		{
+			name: "registered by ip or name",
+			pods: []kapi.Pod{
+				workerPod("spark-w-2-xyzab", "10.1.0.1", "spark:2.1"),
+				workerPod("spark-w-2-abcde", "10.1.0.3", "spark:2.1"),
+			},
+			expected: 2,
+		},
+		{
+			name: "old image ignored",
+			pods: []kapi.Pod{
+				workerPod("spark-w-1-xyzab", "10.1.0.2", "spark:2.0"),
+				workerPod("spark-w-2-xyzab", "10.1.0.1", "spark:2.1"),
+			},
+			expected: 1,
+		},
+		{
+			name: "not registered yet",
+			pods: []kapi.Pod{
+				workerPod("spark-w-2-fghij", "10.1.0.5", "spark:2.1"),
+				workerPod("spark-w-2-klmno", "10.1.0.4", "spark:2.1"),
+			},
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		if count := registeredWorkers(state, test.pods, "spark:2.1"); count != test.expected {
+			t.Errorf("%s: expected %d registered workers, got %d", test.name, test.expected, count)
+		}
+	}
+}